@@ -1,6 +1,15 @@
 package main
 
-import "github.com/unixpickle/learn-quantum/quantum"
+import (
+	"encoding/json"
+
+	"github.com/unixpickle/learn-quantum/quantum"
+)
+
+func init() {
+	quantum.RegisterGate("addition.SlidingGate", &SlidingGate{})
+	quantum.RegisterGate("addition.EndGate", &EndGate{})
+}
 
 // A SlidingGate is a 4-qubit gate that is run
 // sequentially with stride 2.
@@ -41,6 +50,33 @@ func (s *SlidingGate) Inverse() quantum.Gate {
 	return &SlidingGate{Gate: s.Gate, Invert: !s.Invert}
 }
 
+func (s *SlidingGate) MarshalJSON() ([]byte, error) {
+	gate, err := quantum.MarshalGate(s.Gate)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&slidingGateJSON{Gate: gate, Invert: s.Invert})
+}
+
+func (s *SlidingGate) UnmarshalJSON(data []byte) error {
+	var obj slidingGateJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	gate, err := quantum.UnmarshalGate(obj.Gate)
+	if err != nil {
+		return err
+	}
+	s.Gate = gate
+	s.Invert = obj.Invert
+	return nil
+}
+
+type slidingGateJSON struct {
+	Gate   json.RawMessage `json:"gate"`
+	Invert bool            `json:"invert,omitempty"`
+}
+
 // An EndGate is a 4-qubit gate that is run on the final
 // qubits in a circuit.
 type EndGate struct {
@@ -63,3 +99,28 @@ func (e *EndGate) Apply(c quantum.Computer) {
 func (e *EndGate) Inverse() quantum.Gate {
 	return &EndGate{Gate: e.Gate.Inverse()}
 }
+
+func (e *EndGate) MarshalJSON() ([]byte, error) {
+	gate, err := quantum.MarshalGate(e.Gate)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&endGateJSON{Gate: gate})
+}
+
+func (e *EndGate) UnmarshalJSON(data []byte) error {
+	var obj endGateJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	gate, err := quantum.UnmarshalGate(obj.Gate)
+	if err != nil {
+		return err
+	}
+	e.Gate = gate
+	return nil
+}
+
+type endGateJSON struct {
+	Gate json.RawMessage `json:"gate"`
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/learn-quantum/quantum"
+)
+
+func TestSlidingGateJSON(t *testing.T) {
+	inner := quantum.Circuit{&quantum.HGate{Bit: 0}, &quantum.CNotGate{Control: 3, Target: 1}}
+	circuit := quantum.Circuit{
+		&SlidingGate{Gate: inner, Invert: true},
+		&EndGate{Gate: &quantum.TGate{Bit: 2}},
+	}
+	data, err := quantum.MarshalCircuit(8, circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), `"invert":`); n != 1 {
+		t.Fatalf("expected one invert field but got %d in %s", n, data)
+	}
+	decoded, _, err := quantum.UnmarshalCircuit(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != circuit.String() {
+		t.Fatalf("expected %s but got %s", circuit, decoded)
+	}
+	if !decoded[0].(*SlidingGate).Invert {
+		t.Fatal("Invert flag was not preserved")
+	}
+}
@@ -7,10 +7,16 @@ import (
 	"math/rand"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 const epsilon = 1e-8
 
+// DefaultParallelThreshold is the default minimum number
+// of qubits for a Simulation to shard gates across
+// multiple Goroutines.
+const DefaultParallelThreshold = 14
+
 // A Computer is a generic quantum computer.
 type Computer interface {
 	NumBits() int
@@ -25,6 +31,17 @@ type Computer interface {
 type Simulation struct {
 	numBits int
 	Phases  []complex128
+
+	// Workers is the number of Goroutines to use for
+	// updating Phases. If it is less than 2, gates are
+	// applied serially.
+	Workers int
+
+	// ParallelThreshold is the minimum number of qubits
+	// for which Workers are used. Below this, the
+	// overhead of synchronization outweighs the benefit.
+	// If it is 0, DefaultParallelThreshold is used.
+	ParallelThreshold int
 }
 
 // Create a new Simulation with all qubits set to 0.
@@ -69,16 +86,24 @@ func (s *Simulation) InUse(bitIdx int) bool {
 }
 
 func (s *Simulation) Measure(bitIdx int) bool {
+	var lock sync.Mutex
 	var zeroProb float64
 	var oneProb float64
-	for i, ph := range s.Phases {
-		prob := math.Pow(cmplx.Abs(ph), 2)
-		if i&(1<<uint(bitIdx)) != 0 {
-			oneProb += prob
-		} else {
-			zeroProb += prob
+	s.parallelFor(func(start, end int) {
+		var zero, one float64
+		for i, ph := range s.Phases[start:end] {
+			prob := math.Pow(cmplx.Abs(ph), 2)
+			if (i+start)&(1<<uint(bitIdx)) != 0 {
+				one += prob
+			} else {
+				zero += prob
+			}
 		}
-	}
+		lock.Lock()
+		zeroProb += zero
+		oneProb += one
+		lock.Unlock()
+	})
 	isOne := rand.Float64() > zeroProb
 	var scale float64
 	if isOne {
@@ -86,13 +111,15 @@ func (s *Simulation) Measure(bitIdx int) bool {
 	} else {
 		scale = 1 / math.Sqrt(zeroProb)
 	}
-	for i := range s.Phases {
-		if (i&(1<<uint(bitIdx)) != 0) != isOne {
-			s.Phases[i] = 0
-		} else {
-			s.Phases[i] *= complex(scale, 0)
+	s.parallelFor(func(start, end int) {
+		for i := start; i < end; i++ {
+			if (i&(1<<uint(bitIdx)) != 0) != isOne {
+				s.Phases[i] = 0
+			} else {
+				s.Phases[i] *= complex(scale, 0)
+			}
 		}
-	}
+	})
 	return isOne
 }
 
@@ -103,25 +130,32 @@ func (s *Simulation) Unitary(target int, m *Matrix2) {
 
 	// Optimization for T-like gates.
 	if m.M11 == 1 && m.M12 == 0 && m.M21 == 0 {
-		for i := range s.Phases {
-			if i&(1<<uint(target)) == 0 {
-				continue
+		s.parallelFor(func(start, end int) {
+			for i := start; i < end; i++ {
+				if i&(1<<uint(target)) == 0 {
+					continue
+				}
+				s.Phases[i] *= m.M22
 			}
-			s.Phases[i] *= m.M22
-		}
+		})
 		return
 	}
 
-	for i := range s.Phases {
-		if i&(1<<uint(target)) != 0 {
-			continue
+	// Each pair of amplitudes is updated by the shard
+	// containing its first element, so shards never touch
+	// the same pair.
+	s.parallelFor(func(start, end int) {
+		for i := start; i < end; i++ {
+			if i&(1<<uint(target)) != 0 {
+				continue
+			}
+			other := i | (1 << uint(target))
+			p0 := s.Phases[i]
+			p1 := s.Phases[other]
+			s.Phases[i] = m.M11*p0 + m.M12*p1
+			s.Phases[other] = m.M21*p0 + m.M22*p1
 		}
-		other := i | (1 << uint(target))
-		p0 := s.Phases[i]
-		p1 := s.Phases[other]
-		s.Phases[i] = m.M11*p0 + m.M12*p1
-		s.Phases[other] = m.M21*p0 + m.M22*p1
-	}
+	})
 }
 
 func (s *Simulation) CNot(control, target int) {
@@ -133,12 +167,14 @@ func (s *Simulation) CNot(control, target int) {
 	}
 	controlMask := 1 << uint(control)
 	targetMask := 1 << uint(target)
-	for i := range s.Phases {
-		if i&controlMask != 0 && i&targetMask == 0 {
-			other := i | targetMask
-			s.Phases[i], s.Phases[other] = s.Phases[other], s.Phases[i]
+	s.parallelFor(func(start, end int) {
+		for i := start; i < end; i++ {
+			if i&controlMask != 0 && i&targetMask == 0 {
+				other := i | targetMask
+				s.Phases[i], s.Phases[other] = s.Phases[other], s.Phases[i]
+			}
 		}
-	}
+	})
 }
 
 func (s *Simulation) Phase(value []bool) complex128 {
@@ -153,12 +189,14 @@ func (s *Simulation) Phase(value []bool) complex128 {
 
 func (s *Simulation) Copy() *Simulation {
 	res := &Simulation{
-		numBits: s.numBits,
-		Phases:  make([]complex128, len(s.Phases)),
-	}
-	for i, phase := range s.Phases {
-		res.Phases[i] = phase
+		numBits:           s.numBits,
+		Phases:            make([]complex128, len(s.Phases)),
+		Workers:           s.Workers,
+		ParallelThreshold: s.ParallelThreshold,
 	}
+	s.parallelFor(func(start, end int) {
+		copy(res.Phases[start:end], s.Phases[start:end])
+	})
 	return res
 }
 
@@ -177,19 +215,7 @@ func (s *Simulation) String() string {
 		if cmplx.Abs(phase) < epsilon {
 			continue
 		}
-		var coeff string
-		if math.Abs(imag(phase)) < epsilon {
-			coeff = formatFloat(real(phase))
-		} else if math.Abs(real(phase)) < epsilon {
-			coeff = formatFloat(imag(phase)) + "i"
-		} else {
-			if imag(phase) > 0 {
-				coeff = fmt.Sprintf("(%s+%si)", formatFloat(real(phase)), formatFloat(imag(phase)))
-			} else {
-				coeff = fmt.Sprintf("(%s-%si)", formatFloat(real(phase)), formatFloat(-imag(phase)))
-			}
-		}
-		pieces = append(pieces, coeff+s.classicalString(i))
+		pieces = append(pieces, formatPhase(phase)+classicalString(s.numBits, i))
 	}
 	return strings.Join(pieces, " + ")
 }
@@ -207,25 +233,75 @@ func (s *Simulation) Sample() []bool {
 // The gate g must not modify the control bit.
 func (s *Simulation) ControlGate(control int, g Gate) {
 	s1 := s.Copy()
-	for i := range s.Phases {
-		if i&(1<<uint(control)) == 0 {
-			s1.Phases[i] = 0
-		} else {
-			s.Phases[i] = 0
+	s.parallelFor(func(start, end int) {
+		for i := start; i < end; i++ {
+			if i&(1<<uint(control)) == 0 {
+				s1.Phases[i] = 0
+			} else {
+				s.Phases[i] = 0
+			}
 		}
-	}
+	})
 	g.Apply(s1)
-	for i, phase := range s1.Phases {
-		if phase != 0 && s.Phases[i] != 0 {
-			panic("gate must not modify control bit")
+	var lock sync.Mutex
+	var modified bool
+	s.parallelFor(func(start, end int) {
+		for i := start; i < end; i++ {
+			phase := s1.Phases[i]
+			if phase != 0 && s.Phases[i] != 0 {
+				lock.Lock()
+				modified = true
+				lock.Unlock()
+				return
+			}
+			s.Phases[i] += phase
 		}
-		s.Phases[i] += phase
+	})
+	if modified {
+		panic("gate must not modify control bit")
+	}
+}
+
+// parallelFor calls f on disjoint ranges of indices
+// which cover s.Phases, possibly from multiple
+// Goroutines at once.
+func (s *Simulation) parallelFor(f func(start, end int)) {
+	threshold := s.ParallelThreshold
+	if threshold == 0 {
+		threshold = DefaultParallelThreshold
+	}
+	if s.Workers < 2 || s.numBits < threshold {
+		f(0, len(s.Phases))
+		return
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < s.Workers; i++ {
+		start := len(s.Phases) * i / s.Workers
+		end := len(s.Phases) * (i + 1) / s.Workers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f(start, end)
+		}()
+	}
+	wg.Wait()
+}
+
+func formatPhase(phase complex128) string {
+	if math.Abs(imag(phase)) < epsilon {
+		return formatFloat(real(phase))
+	} else if math.Abs(real(phase)) < epsilon {
+		return formatFloat(imag(phase)) + "i"
+	} else if imag(phase) > 0 {
+		return fmt.Sprintf("(%s+%si)", formatFloat(real(phase)), formatFloat(imag(phase)))
+	} else {
+		return fmt.Sprintf("(%s-%si)", formatFloat(real(phase)), formatFloat(-imag(phase)))
 	}
 }
 
-func (s *Simulation) classicalString(i int) string {
+func classicalString(numBits, i int) string {
 	res := ""
-	for j := 0; j < s.numBits; j++ {
+	for j := 0; j < numBits; j++ {
 		res += strconv.Itoa((i & (1 << uint(j))) >> uint(j))
 	}
 	return "|" + res + ">"
@@ -145,3 +145,42 @@ func TestSimulationInterference(t *testing.T) {
 		}
 	}
 }
+
+func TestSimulationParallel(t *testing.T) {
+	serial := RandomSimulation(8)
+	parallel := serial.Copy()
+	parallel.Workers = 3
+	parallel.ParallelThreshold = 1
+
+	circuit := randomizedCircuit(rand.Perm(8), 1337, 50)
+	circuit = append(circuit, &ClassicalGate{
+		F: func(b []bool) []bool {
+			res := append([]bool{}, b...)
+			res[0], res[5] = res[5], res[0]
+			return res
+		},
+	})
+	circuit.Apply(serial)
+	circuit.Apply(parallel)
+	if !serial.ApproxEqual(parallel, 1e-8) {
+		t.Fatal("mismatched circuit result")
+	}
+
+	serial.ControlGate(2, &HGate{Bit: 3})
+	parallel.ControlGate(2, &HGate{Bit: 3})
+	if !serial.ApproxEqual(parallel, 1e-8) {
+		t.Fatal("mismatched control gate result")
+	}
+
+	for i := 0; i < 100; i++ {
+		s1 := serial.Copy()
+		p1 := parallel.Copy()
+		if s1.Measure(4) == p1.Measure(4) {
+			if !s1.ApproxEqual(p1, 1e-8) {
+				t.Fatal("mismatched measurement result")
+			}
+			return
+		}
+	}
+	t.Fatal("measurements never agreed")
+}
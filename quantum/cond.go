@@ -1,9 +1,6 @@
 package quantum
 
-import (
-	"math"
-	"math/cmplx"
-)
+import "math/cmplx"
 
 // Cond runs a function that only has an effect if a given
 // control bit is set. The function should not attempt to
@@ -84,50 +81,27 @@ func CUnitary(c Computer, control, target int, m *Matrix2) {
 	}
 
 	// https://arxiv.org/abs/quant-ph/9503016
+	//
+	// With m = e^(i*phase)*RZ(a)*RY(b)*RZ(g), we apply
+	// A*X*B*X*C where A*B*C = I.
+	angles := EulerDecompose(m, EulerZYZ)
+	a, b, g := angles.Alpha, angles.Beta, angles.Gamma
 
-	theta := 2 * math.Atan2(cmplx.Abs(m.M12), cmplx.Abs(m.M11))
+	matA := (&RZGate{Theta: a}).Matrix()
+	matA1 := (&RYGate{Theta: b / 2}).Matrix()
+	matA.Mul(&matA1)
 
-	a := imag(cmplx.Log(m.M11 / -m.M21))
-	var b, delta float64
-	if cmplx.Abs(m.M11) > cmplx.Abs(m.M21) {
-		b = imag(cmplx.Log((m.M11 / m.M22) * cmplx.Exp(complex(0, -a))))
-		delta = imag(cmplx.Log(m.M11 / cmplx.Exp(complex(0, a/2+b/2))))
-	} else {
-		b = imag(cmplx.Log((-m.M21 / m.M12) * cmplx.Exp(complex(0, a))))
-		delta = imag(cmplx.Log(m.M12 / cmplx.Exp(complex(0, a/2-b/2))))
-	}
-
-	mat1 := rotateZ(a)
-	mat1A := rotateY(theta / 2)
-	mat1.Mul(&mat1A)
+	matB := (&RYGate{Theta: -b / 2}).Matrix()
+	matB1 := (&RZGate{Theta: -(a + g) / 2}).Matrix()
+	matB.Mul(&matB1)
 
-	mat2 := rotateY(-theta / 2)
-	mat2A := rotateZ(-(a + b) / 2)
-	mat2.Mul(&mat2A)
+	matC := (&RZGate{Theta: (g - a) / 2}).Matrix()
+	phase := (&PhaseGate{Phi: angles.Phase}).Matrix()
 
-	mat3 := rotateZ((b - a) / 2)
-	mat4 := phaseShift(delta)
-
-	c.Unitary(target, &mat3)
+	c.Unitary(target, &matC)
 	c.CNot(control, target)
-	c.Unitary(target, &mat2)
+	c.Unitary(target, &matB)
 	c.CNot(control, target)
-	c.Unitary(target, &mat1)
-	CUnitary(c, control, target, &mat4)
-}
-
-func rotateY(theta float64) Matrix2 {
-	cos := complex(math.Cos(theta/2), 0)
-	sin := complex(math.Sin(theta/2), 0)
-	return Matrix2{cos, sin, -sin, cos}
-}
-
-func rotateZ(alpha float64) Matrix2 {
-	num := cmplx.Exp(complex(0, alpha/2))
-	return Matrix2{num, 0, 0, cmplx.Conj(num)}
-}
-
-func phaseShift(delta float64) Matrix2 {
-	num := cmplx.Exp(complex(0, delta))
-	return Matrix2{num, 0, 0, num}
+	c.Unitary(target, &matA)
+	c.Unitary(control, &phase)
 }
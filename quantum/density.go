@@ -0,0 +1,206 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+)
+
+// A DensitySimulation is a classical simulation of a
+// quantum computer which stores a density matrix rather
+// than a pure state. This makes it possible to represent
+// mixed states.
+type DensitySimulation struct {
+	numBits int
+
+	// Rho is the 2^n x 2^n density matrix, stored in
+	// row-major order.
+	Rho []complex128
+}
+
+// Create a new DensitySimulation with all qubits set to 0.
+func NewDensitySimulation(numBits int) *DensitySimulation {
+	return NewDensitySimulationBits(numBits, 0)
+}
+
+// Create a new DensitySimulation with a given bit-string.
+func NewDensitySimulationBits(numBits int, value uint) *DensitySimulation {
+	size := 1 << uint(numBits)
+	d := &DensitySimulation{
+		numBits: numBits,
+		Rho:     make([]complex128, size*size),
+	}
+	d.Rho[int(value)*size+int(value)] = 1
+	return d
+}
+
+// Create a DensitySimulation for the pure state of a
+// Simulation.
+func NewDensitySimulationPure(s *Simulation) *DensitySimulation {
+	size := len(s.Phases)
+	d := &DensitySimulation{
+		numBits: s.NumBits(),
+		Rho:     make([]complex128, size*size),
+	}
+	for i, p1 := range s.Phases {
+		for j, p2 := range s.Phases {
+			d.Rho[i*size+j] = p1 * cmplx.Conj(p2)
+		}
+	}
+	return d
+}
+
+func (d *DensitySimulation) NumBits() int {
+	return d.numBits
+}
+
+func (d *DensitySimulation) InUse(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= d.numBits {
+		panic("bit index out of range")
+	}
+	return false
+}
+
+func (d *DensitySimulation) Measure(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= d.numBits {
+		panic("bit index out of range")
+	}
+	size := d.size()
+	mask := 1 << uint(bitIdx)
+	var oneProb float64
+	for i := 0; i < size; i++ {
+		if i&mask != 0 {
+			oneProb += real(d.Rho[i*size+i])
+		}
+	}
+	isOne := rand.Float64() < oneProb
+	var scale complex128
+	if isOne {
+		scale = complex(1/oneProb, 0)
+	} else {
+		scale = complex(1/(1-oneProb), 0)
+	}
+	for i := 0; i < size; i++ {
+		rowMatch := (i&mask != 0) == isOne
+		for j := 0; j < size; j++ {
+			idx := i*size + j
+			if rowMatch && (j&mask != 0) == isOne {
+				d.Rho[idx] *= scale
+			} else {
+				d.Rho[idx] = 0
+			}
+		}
+	}
+	return isOne
+}
+
+func (d *DensitySimulation) Unitary(target int, m *Matrix2) {
+	if target < 0 || target >= d.numBits {
+		panic("bit index out of range")
+	}
+	size := d.size()
+	mask := 1 << uint(target)
+
+	// Compute rho <- m*rho by mixing pairs of rows.
+	for i := 0; i < size; i++ {
+		if i&mask != 0 {
+			continue
+		}
+		row0 := d.Rho[i*size : (i+1)*size]
+		row1 := d.Rho[(i|mask)*size : ((i|mask)+1)*size]
+		for j := range row0 {
+			p0, p1 := row0[j], row1[j]
+			row0[j] = m.M11*p0 + m.M12*p1
+			row1[j] = m.M21*p0 + m.M22*p1
+		}
+	}
+
+	// Compute rho <- rho*m^H by mixing pairs of columns.
+	c11, c12 := cmplx.Conj(m.M11), cmplx.Conj(m.M12)
+	c21, c22 := cmplx.Conj(m.M21), cmplx.Conj(m.M22)
+	for i := 0; i < size; i++ {
+		row := d.Rho[i*size : (i+1)*size]
+		for j := 0; j < size; j++ {
+			if j&mask != 0 {
+				continue
+			}
+			p0, p1 := row[j], row[j|mask]
+			row[j] = p0*c11 + p1*c12
+			row[j|mask] = p0*c21 + p1*c22
+		}
+	}
+}
+
+func (d *DensitySimulation) CNot(control, target int) {
+	if control < 0 || control >= d.numBits || target < 0 || target >= d.numBits {
+		panic("bit index out of range")
+	}
+	if control == target {
+		panic("overlapping control and target is not invertible")
+	}
+	size := d.size()
+	controlMask := 1 << uint(control)
+	targetMask := 1 << uint(target)
+
+	// Permute rows, then permute columns.
+	for i := 0; i < size; i++ {
+		if i&controlMask != 0 && i&targetMask == 0 {
+			row0 := d.Rho[i*size : (i+1)*size]
+			row1 := d.Rho[(i|targetMask)*size : ((i|targetMask)+1)*size]
+			for j := range row0 {
+				row0[j], row1[j] = row1[j], row0[j]
+			}
+		}
+	}
+	for i := 0; i < size; i++ {
+		row := d.Rho[i*size : (i+1)*size]
+		for j := 0; j < size; j++ {
+			if j&controlMask != 0 && j&targetMask == 0 {
+				row[j], row[j|targetMask] = row[j|targetMask], row[j]
+			}
+		}
+	}
+}
+
+// Probabilities computes the probability of observing
+// each classical basis state.
+func (d *DensitySimulation) Probabilities() []float64 {
+	size := d.size()
+	res := make([]float64, size)
+	for i := range res {
+		res[i] = real(d.Rho[i*size+i])
+	}
+	return res
+}
+
+// Purity computes the trace of rho^2, which is 1 for pure
+// states and 2^-n for the maximally mixed state.
+func (d *DensitySimulation) Purity() float64 {
+	// Since rho is Hermitian, tr(rho^2) is the sum of
+	// squared magnitudes of its entries.
+	var res float64
+	for _, x := range d.Rho {
+		res += math.Pow(cmplx.Abs(x), 2)
+	}
+	return res
+}
+
+func (d *DensitySimulation) Copy() *DensitySimulation {
+	return &DensitySimulation{
+		numBits: d.numBits,
+		Rho:     append([]complex128{}, d.Rho...),
+	}
+}
+
+func (d *DensitySimulation) ApproxEqual(d1 *DensitySimulation, tol float64) bool {
+	for i, x := range d.Rho {
+		if cmplx.Abs(x-d1.Rho[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *DensitySimulation) size() int {
+	return 1 << uint(d.numBits)
+}
@@ -0,0 +1,84 @@
+package quantum
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDensitySimulationGates(t *testing.T) {
+	circuit := Circuit{
+		&HGate{Bit: 0},
+		&HGate{Bit: 3},
+		&SqrtNotGate{Bit: 1},
+		&CSqrtNotGate{Control: 2, Target: 1},
+		&TGate{Bit: 0},
+		&YGate{Bit: 2},
+		&CCNotGate{Control1: 2, Control2: 0, Target: 3},
+		&CSwapGate{Control: 1, A: 0, B: 2},
+	}
+	s := RandomSimulation(4)
+	d := NewDensitySimulationPure(s)
+	circuit.Apply(s)
+	circuit.Apply(d)
+	if !d.ApproxEqual(NewDensitySimulationPure(s), 1e-8) {
+		t.Error("forward result mismatch")
+	}
+
+	Invert(d, circuit.Apply)
+	Invert(s, circuit.Apply)
+	if !d.ApproxEqual(NewDensitySimulationPure(s), 1e-8) {
+		t.Error("inverse result mismatch")
+	}
+}
+
+func TestDensitySimulationArithmetic(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		s := RandomSimulation(7)
+		d := NewDensitySimulationPure(s)
+		bits := rand.Perm(7)
+		for _, c := range []Computer{s, d} {
+			Add(c, bits[:3], bits[3:6], &bits[6])
+			Cond(c, bits[0], func(c Computer) {
+				ToffoliN(c, bits[1], bits[2], bits[3], bits[4])
+			})
+		}
+		if !d.ApproxEqual(NewDensitySimulationPure(s), 1e-8) {
+			t.Fatal("incorrect result")
+		}
+	}
+}
+
+func TestDensitySimulationModAdd(t *testing.T) {
+	source, target, modulus := Reg{0, 1}, Reg{2, 3}, Reg{4, 5}
+	var state uint
+	state = source.Inject(state, 2)
+	state = target.Inject(state, 1)
+	state = modulus.Inject(state, 3)
+	d := NewDensitySimulationBits(7, state)
+	ModAdd(d, source, target, modulus, 6)
+	expected := target.Inject(state, 0)
+	if p := d.Probabilities()[expected]; math.Abs(p-1) > 1e-8 {
+		t.Errorf("expected probability 1 but got %f", p)
+	}
+}
+
+func TestDensitySimulationMeasure(t *testing.T) {
+	d := NewDensitySimulation(2)
+	H(d, 0)
+	d.CNot(0, 1)
+	if math.Abs(d.Purity()-1) > 1e-8 {
+		t.Error("unexpected purity", d.Purity())
+	}
+	probs := d.Probabilities()
+	if math.Abs(probs[0]-0.5) > 1e-8 || math.Abs(probs[3]-0.5) > 1e-8 {
+		t.Error("unexpected probabilities", probs)
+	}
+	res := d.Measure(0)
+	if d.Measure(1) != res {
+		t.Error("measurements should be correlated")
+	}
+	if math.Abs(d.Purity()-1) > 1e-8 {
+		t.Error("unexpected purity after measurement", d.Purity())
+	}
+}
@@ -0,0 +1,278 @@
+//go:build linux
+// +build linux
+
+package quantum
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	diskMagic      = "QSTATE01"
+	diskHeaderSize = 16
+
+	// diskMaxBits is the largest number of qubits whose
+	// state size fits in an int64.
+	diskMaxBits = 58
+)
+
+var errDiskClosed = errors.New("disk simulation: already closed")
+
+// A DiskSimulation is a classical simulation of a quantum
+// computer whose amplitudes are stored in a memory-mapped
+// file rather than on the heap. This makes it possible to
+// simulate more qubits than fit in RAM, and to checkpoint
+// long-running simulations.
+//
+// Gates stream through the file sequentially so that the
+// operating system can page it in and out efficiently.
+type DiskSimulation struct {
+	numBits int
+	file    *os.File
+	data    []byte
+
+	// Phases is a view of the amplitudes in the file.
+	// It is invalid after Close.
+	Phases []complex128
+}
+
+// CreateDiskSimulation creates a new state file with all
+// qubits set to 0, overwriting any existing file.
+func CreateDiskSimulation(path string, numBits int) (*DiskSimulation, error) {
+	if numBits < 0 || numBits > diskMaxBits {
+		return nil, errors.New("create disk simulation: invalid number of qubits")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	size := int64(diskHeaderSize) + int64(16)<<uint(numBits)
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	header := make([]byte, diskHeaderSize)
+	copy(header, diskMagic)
+	binary.LittleEndian.PutUint64(header[8:], uint64(numBits))
+	if _, err := f.WriteAt(header, 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	d, err := mapDiskSimulation(f, numBits)
+	if err != nil {
+		return nil, err
+	}
+	d.Phases[0] = 1
+	return d, nil
+}
+
+// OpenDiskSimulation opens a state file that was created
+// by CreateDiskSimulation, for example to resume from a
+// checkpoint.
+func OpenDiskSimulation(path string) (*DiskSimulation, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, diskHeaderSize)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if string(header[:8]) != diskMagic {
+		f.Close()
+		return nil, errors.New("open disk simulation: invalid file header")
+	}
+	rawBits := binary.LittleEndian.Uint64(header[8:])
+	if rawBits > diskMaxBits {
+		f.Close()
+		return nil, errors.New("open disk simulation: invalid number of qubits")
+	}
+	numBits := int(rawBits)
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Size() != int64(diskHeaderSize)+int64(16)<<uint(numBits) {
+		f.Close()
+		return nil, errors.New("open disk simulation: unexpected file size")
+	}
+	return mapDiskSimulation(f, numBits)
+}
+
+func mapDiskSimulation(f *os.File, numBits int) (*DiskSimulation, error) {
+	numPhases := 1 << uint(numBits)
+	data, err := syscall.Mmap(int(f.Fd()), 0, diskHeaderSize+16*numPhases,
+		syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	syscall.Madvise(data, syscall.MADV_SEQUENTIAL)
+	return &DiskSimulation{
+		numBits: numBits,
+		file:    f,
+		data:    data,
+		Phases:  unsafe.Slice((*complex128)(unsafe.Pointer(&data[diskHeaderSize])), numPhases),
+	}, nil
+}
+
+// Flush writes the current state to disk, so that it can
+// be recovered with OpenDiskSimulation even if the
+// process exits unexpectedly.
+func (d *DiskSimulation) Flush() error {
+	if d.data == nil {
+		return errDiskClosed
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&d.data[0])),
+		uintptr(len(d.data)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Close flushes the state and releases the file.
+//
+// After Close, both Flush and Close return an error.
+func (d *DiskSimulation) Close() error {
+	if d.data == nil {
+		return errDiskClosed
+	}
+	flushErr := d.Flush()
+	unmapErr := syscall.Munmap(d.data)
+	closeErr := d.file.Close()
+	d.data = nil
+	d.Phases = nil
+	for _, err := range []error{flushErr, unmapErr, closeErr} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DiskSimulation) NumBits() int {
+	return d.numBits
+}
+
+func (d *DiskSimulation) InUse(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= d.numBits {
+		panic("bit index out of range")
+	}
+	return false
+}
+
+func (d *DiskSimulation) Measure(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= d.numBits {
+		panic("bit index out of range")
+	}
+	var zeroProb float64
+	var oneProb float64
+	d.forEachPair(bitIdx, func(_ int, lo, hi []complex128) {
+		for i, p0 := range lo {
+			zeroProb += math.Pow(cmplx.Abs(p0), 2)
+			oneProb += math.Pow(cmplx.Abs(hi[i]), 2)
+		}
+	})
+	isOne := rand.Float64()*(zeroProb+oneProb) > zeroProb
+	var scale complex128
+	if isOne {
+		scale = complex(1/math.Sqrt(oneProb), 0)
+	} else {
+		scale = complex(1/math.Sqrt(zeroProb), 0)
+	}
+	d.forEachPair(bitIdx, func(_ int, lo, hi []complex128) {
+		for i := range lo {
+			if isOne {
+				lo[i] = 0
+				hi[i] *= scale
+			} else {
+				lo[i] *= scale
+				hi[i] = 0
+			}
+		}
+	})
+	return isOne
+}
+
+func (d *DiskSimulation) Unitary(target int, m *Matrix2) {
+	if target < 0 || target >= d.numBits {
+		panic("bit index out of range")
+	}
+
+	// Optimization for T-like gates.
+	if m.M11 == 1 && m.M12 == 0 && m.M21 == 0 {
+		d.forEachPair(target, func(_ int, _, hi []complex128) {
+			for i := range hi {
+				hi[i] *= m.M22
+			}
+		})
+		return
+	}
+
+	d.forEachPair(target, func(_ int, lo, hi []complex128) {
+		for i, p0 := range lo {
+			p1 := hi[i]
+			lo[i] = m.M11*p0 + m.M12*p1
+			hi[i] = m.M21*p0 + m.M22*p1
+		}
+	})
+}
+
+func (d *DiskSimulation) CNot(control, target int) {
+	if control < 0 || control >= d.numBits || target < 0 || target >= d.numBits {
+		panic("bit index out of range")
+	}
+	if control == target {
+		panic("overlapping control and target is not invertible")
+	}
+	controlMask := 1 << uint(control)
+	d.forEachPair(target, func(start int, lo, hi []complex128) {
+		for i := range lo {
+			if (start+i)&controlMask != 0 {
+				lo[i], hi[i] = hi[i], lo[i]
+			}
+		}
+	})
+}
+
+func (d *DiskSimulation) Phase(value []bool) complex128 {
+	var idx int
+	for i, x := range value {
+		if x {
+			idx |= 1 << uint(i)
+		}
+	}
+	return d.Phases[idx]
+}
+
+// Simulation copies the state into memory.
+func (d *DiskSimulation) Simulation() *Simulation {
+	return &Simulation{
+		numBits: d.numBits,
+		Phases:  append([]complex128{}, d.Phases...),
+	}
+}
+
+// forEachPair calls f with contiguous blocks of
+// amplitudes where the bit is 0 (lo) and the matching
+// amplitudes where the bit is 1 (hi).
+//
+// Blocks are visited in order, so that each gate makes a
+// single sequential pass over the file, regardless of
+// which qubit it acts on.
+func (d *DiskSimulation) forEachPair(bit int, f func(start int, lo, hi []complex128)) {
+	stride := 1 << uint(bit)
+	for base := 0; base < len(d.Phases); base += 2 * stride {
+		f(base, d.Phases[base:base+stride], d.Phases[base+stride:base+2*stride])
+	}
+}
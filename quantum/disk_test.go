@@ -0,0 +1,103 @@
+//go:build linux
+// +build linux
+
+package quantum
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskSimulation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	d, err := CreateDiskSimulation(path, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	s := NewSimulation(8)
+	for i := 0; i < 10; i++ {
+		circuit := randomizedCircuit(rand.Perm(8), rand.Int(), 30)
+		circuit.Apply(s)
+		circuit.Apply(d)
+		if !d.Simulation().ApproxEqual(s, 1e-8) {
+			t.Fatal("incorrect result")
+		}
+	}
+}
+
+func TestDiskSimulationCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	d, err := CreateDiskSimulation(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSimulation(10)
+	for _, c := range []Computer{s, d} {
+		for i := 0; i < 9; i++ {
+			H(c, i)
+		}
+		ModAdd(c, Reg{0, 1, 2}, Reg{3, 4, 5}, Reg{6, 7, 8}, 9)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err = OpenDiskSimulation(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if d.NumBits() != 10 {
+		t.Fatal("unexpected number of bits", d.NumBits())
+	}
+	for _, c := range []Computer{s, d} {
+		ModAdd(c, Reg{0, 1, 2}, Reg{3, 4, 5}, Reg{6, 7, 8}, 9)
+	}
+	if !d.Simulation().ApproxEqual(s, 1e-8) {
+		t.Fatal("incorrect result after reopening")
+	}
+}
+
+func TestDiskSimulationErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	d, err := CreateDiskSimulation(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err == nil {
+		t.Error("expected error from second Close")
+	}
+	if err := d.Flush(); err == nil {
+		t.Error("expected error from Flush after Close")
+	}
+
+	for _, numBits := range []int{-1, diskMaxBits + 1} {
+		badPath := filepath.Join(t.TempDir(), "bad")
+		if _, err := CreateDiskSimulation(badPath, numBits); err == nil {
+			t.Errorf("expected error creating %d qubits", numBits)
+		}
+		if _, err := os.Stat(badPath); !os.IsNotExist(err) {
+			t.Errorf("unexpected file after creating %d qubits", numBits)
+		}
+	}
+
+	for _, numBits := range []uint64{3, 64, 1 << 40} {
+		header := make([]byte, diskHeaderSize+16*4)
+		copy(header, diskMagic)
+		binary.LittleEndian.PutUint64(header[8:], numBits)
+		if err := os.WriteFile(path, header, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if d, err := OpenDiskSimulation(path); err == nil {
+			d.Close()
+			t.Errorf("expected error for %d qubits", numBits)
+		}
+	}
+}
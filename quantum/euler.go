@@ -0,0 +1,171 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// An EulerBasis specifies the rotation axes used by an
+// Euler angle decomposition.
+type EulerBasis int
+
+const (
+	// EulerZYZ decomposes a unitary as RZ*RY*RZ.
+	EulerZYZ EulerBasis = iota
+
+	// EulerZXZ decomposes a unitary as RZ*RX*RZ.
+	EulerZXZ
+
+	// EulerXZX decomposes a unitary as RX*RZ*RX.
+	EulerXZX
+)
+
+func (e EulerBasis) String() string {
+	switch e {
+	case EulerZYZ:
+		return "ZYZ"
+	case EulerZXZ:
+		return "ZXZ"
+	case EulerXZX:
+		return "XZX"
+	}
+	return "EulerBasis(?)"
+}
+
+// EulerAngles describe a single-qubit unitary as
+//
+//	e^(i*Phase) * R1(Alpha) * R2(Beta) * R1(Gamma)
+//
+// where R1 and R2 are the rotations from the basis, such
+// as RZ and RY for EulerZYZ. In a circuit, the rotation
+// by Gamma is applied first.
+type EulerAngles struct {
+	Basis EulerBasis
+	Phase float64
+	Alpha float64
+	Beta  float64
+	Gamma float64
+}
+
+// EulerDecompose computes Euler angles for a unitary.
+//
+// Phase, Alpha, and Gamma are in the range (-pi, pi], and
+// Beta is in the range [0, pi]. When Beta is 0 or pi, the
+// decomposition is not unique, so Alpha is set to 0 when
+// Beta is 0, and Gamma is set to 0 when Beta is pi.
+func EulerDecompose(m *Matrix2, basis EulerBasis) *EulerAngles {
+	switch basis {
+	case EulerZXZ:
+		// RX(b) = RZ(-pi/2)*RY(b)*RZ(pi/2).
+		res := EulerDecompose(m, EulerZYZ)
+		res.Basis = EulerZXZ
+		res.Alpha += math.Pi / 2
+		res.Gamma -= math.Pi / 2
+
+		// Keep the same convention as ZYZ for the degenerate
+		// cases, using RZ(a)*RX(pi) = RX(pi)*RZ(-a).
+		if math.Sin(res.Beta/2) < epsilon {
+			res.Gamma += res.Alpha
+			res.Alpha = 0
+		} else if math.Cos(res.Beta/2) < epsilon {
+			res.Alpha -= res.Gamma
+			res.Gamma = 0
+		}
+		res.normalize()
+		return res
+	case EulerXZX:
+		// Conjugating by H swaps the X and Z axes.
+		h := Matrix2{complex(1/math.Sqrt2, 0), complex(1/math.Sqrt2, 0),
+			complex(1/math.Sqrt2, 0), complex(-1/math.Sqrt2, 0)}
+		conj := h
+		conj.Mul(m)
+		conj.Mul(&h)
+		res := EulerDecompose(&conj, EulerZXZ)
+		res.Basis = EulerXZX
+		return res
+	}
+
+	// Write m = e^(i*phase)*v for v in SU(2), where
+	//
+	//	v = [[e^(-i(a+g)/2)*cos(b/2), -e^(-i(a-g)/2)*sin(b/2)],
+	//	     [e^(i(a-g)/2)*sin(b/2), e^(i(a+g)/2)*cos(b/2)]].
+	phase := cmplx.Sqrt(m.Det())
+	v21 := m.M21 / phase
+	v22 := m.M22 / phase
+
+	res := &EulerAngles{
+		Basis: EulerZYZ,
+		Phase: cmplx.Phase(phase),
+		Beta:  2 * math.Atan2(cmplx.Abs(v21), cmplx.Abs(v22)),
+	}
+	if cmplx.Abs(v21) < epsilon {
+		res.Gamma = 2 * cmplx.Phase(v22)
+	} else if cmplx.Abs(v22) < epsilon {
+		res.Alpha = 2 * cmplx.Phase(v21)
+	} else {
+		halfSum := cmplx.Phase(v22)
+		halfDiff := cmplx.Phase(v21)
+		res.Alpha = halfSum + halfDiff
+		res.Gamma = halfSum - halfDiff
+	}
+	res.normalize()
+	return res
+}
+
+// Matrix computes the unitary described by the angles.
+func (e *EulerAngles) Matrix() Matrix2 {
+	res := e.rotation(e.Alpha, true)
+	r2 := e.rotation(e.Beta, false)
+	r3 := e.rotation(e.Gamma, true)
+	res.Mul(&r2)
+	res.Mul(&r3)
+	phase := cmplx.Exp(complex(0, e.Phase))
+	return Matrix2{phase * res.M11, phase * res.M12, phase * res.M21, phase * res.M22}
+}
+
+// Circuit creates the rotation gates on a qubit, which
+// apply the unitary up to global phase.
+func (e *EulerAngles) Circuit(bit int) Circuit {
+	return Circuit{
+		e.rotationGate(bit, e.Gamma, true),
+		e.rotationGate(bit, e.Beta, false),
+		e.rotationGate(bit, e.Alpha, true),
+	}
+}
+
+func (e *EulerAngles) rotation(theta float64, outer bool) Matrix2 {
+	return e.rotationGate(0, theta, outer).(interface{ Matrix() Matrix2 }).Matrix()
+}
+
+func (e *EulerAngles) rotationGate(bit int, theta float64, outer bool) Gate {
+	axes := map[EulerBasis][2]string{
+		EulerZYZ: {"RZ", "RY"},
+		EulerZXZ: {"RZ", "RX"},
+		EulerXZX: {"RX", "RZ"},
+	}[e.Basis]
+	if outer {
+		return rotationGate(axes[0], bit, []float64{theta})
+	}
+	return rotationGate(axes[1], bit, []float64{theta})
+}
+
+// normalize wraps Alpha, Gamma, and Phase into (-pi, pi].
+//
+// Shifting a rotation angle by 2*pi negates the rotation,
+// which is undone by shifting Phase by pi.
+func (e *EulerAngles) normalize() {
+	for _, angle := range []*float64{&e.Alpha, &e.Gamma} {
+		for *angle > math.Pi {
+			*angle -= 2 * math.Pi
+			e.Phase += math.Pi
+		}
+		for *angle <= -math.Pi {
+			*angle += 2 * math.Pi
+			e.Phase += math.Pi
+		}
+	}
+	e.Phase = math.Remainder(e.Phase, 2*math.Pi)
+	if e.Phase <= -math.Pi {
+		e.Phase += 2 * math.Pi
+	}
+}
@@ -0,0 +1,86 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+var eulerBases = []EulerBasis{EulerZYZ, EulerZXZ, EulerXZX}
+
+func TestEulerDecompose(t *testing.T) {
+	for _, basis := range eulerBases {
+		t.Run(basis.String(), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				m := RandomMatrix2()
+				testEulerDecompose(t, &m, basis)
+			}
+		})
+	}
+}
+
+func TestEulerDecomposeDegenerate(t *testing.T) {
+	s2 := complex(1/math.Sqrt2, 0)
+	matrices := []Matrix2{
+		{1, 0, 0, 1},
+		{1, 0, 0, -1},
+		{1, 0, 0, 1i},
+		{-1, 0, 0, -1},
+		{cmplx.Exp(0.3i), 0, 0, cmplx.Exp(-2i)},
+		{0, 1, 1, 0},
+		{0, -1i, 1i, 0},
+		{0, 1i, 1i, 0},
+		{0, cmplx.Exp(1i), cmplx.Exp(-0.5i), 0},
+		{s2, s2, s2, -s2},
+	}
+	for _, basis := range eulerBases {
+		t.Run(basis.String(), func(t *testing.T) {
+			for _, m := range matrices {
+				testEulerDecompose(t, &m, basis)
+				angles := EulerDecompose(&m, basis)
+				if angles.Beta < epsilon && angles.Alpha != 0 {
+					t.Errorf("matrix %v: expected zero alpha but got %f", m, angles.Alpha)
+				} else if angles.Beta > math.Pi-epsilon && angles.Gamma != 0 {
+					t.Errorf("matrix %v: expected zero gamma but got %f", m, angles.Gamma)
+				}
+			}
+		})
+	}
+}
+
+func TestEulerAnglesCircuit(t *testing.T) {
+	for _, basis := range eulerBases {
+		for i := 0; i < 10; i++ {
+			m := RandomMatrix2()
+			angles := EulerDecompose(&m, basis)
+			actual := circuitMatrix2(angles.Circuit(0))
+			if d := m.PhaseDistance(&actual); d > 1e-7 {
+				t.Errorf("%s: unexpected circuit distance %e", basis, d)
+			}
+		}
+	}
+}
+
+func testEulerDecompose(t *testing.T, m *Matrix2, basis EulerBasis) {
+	angles := EulerDecompose(m, basis)
+	if angles.Basis != basis {
+		t.Fatalf("unexpected basis: %s", angles.Basis)
+	}
+	for _, angle := range []float64{angles.Phase, angles.Alpha, angles.Gamma} {
+		if angle <= -math.Pi || angle > math.Pi {
+			t.Errorf("angle out of range: %f", angle)
+		}
+	}
+	if angles.Beta < 0 || angles.Beta > math.Pi+epsilon {
+		t.Errorf("beta out of range: %f", angles.Beta)
+	}
+	actual := angles.Matrix()
+	diff := actual
+	diff.M11 -= m.M11
+	diff.M12 -= m.M12
+	diff.M21 -= m.M21
+	diff.M22 -= m.M22
+	if norm := matrix2Norm(&diff); norm > 1e-7 {
+		t.Errorf("matrix %v: expected exact reconstruction but got %v", *m, actual)
+	}
+}
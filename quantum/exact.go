@@ -0,0 +1,426 @@
+package quantum
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxExactExp is the largest denominator exponent tried
+// when converting a Matrix2 to an ExactMatrix2.
+const maxExactExp = 30
+
+// A ZOmega is an element a + b*w + c*w^2 + d*w^3 of the
+// ring Z[w], where w = e^(i*pi/4), stored as [a, b, c, d].
+type ZOmega [4]int64
+
+func (z ZOmega) Add(other ZOmega) ZOmega {
+	return ZOmega{z[0] + other[0], z[1] + other[1], z[2] + other[2], z[3] + other[3]}
+}
+
+func (z ZOmega) Neg() ZOmega {
+	return ZOmega{-z[0], -z[1], -z[2], -z[3]}
+}
+
+func (z ZOmega) Mul(other ZOmega) ZOmega {
+	// Since w^4 = -1, powers past w^3 wrap around with a
+	// negative sign.
+	var res ZOmega
+	for i, x := range z {
+		for j, y := range other {
+			if i+j < 4 {
+				res[i+j] += x * y
+			} else {
+				res[i+j-4] -= x * y
+			}
+		}
+	}
+	return res
+}
+
+// Conj computes the complex conjugate.
+func (z ZOmega) Conj() ZOmega {
+	// The conjugate of w^k is w^(8-k) = -w^(4-k).
+	return ZOmega{z[0], -z[3], -z[2], -z[1]}
+}
+
+func (z ZOmega) Complex() complex128 {
+	h := math.Sqrt2 / 2
+	return complex(float64(z[0])+h*float64(z[1]-z[3]), float64(z[2])+h*float64(z[1]+z[3]))
+}
+
+// mulSqrt2 multiplies by sqrt(2) = w - w^3.
+func (z ZOmega) mulSqrt2() ZOmega {
+	return ZOmega{z[1] - z[3], z[0] + z[2], z[1] + z[3], z[2] - z[0]}
+}
+
+// divSqrt2 divides by sqrt(2) if the result is in Z[w].
+func (z ZOmega) divSqrt2() (ZOmega, bool) {
+	if (z[0]-z[2])%2 != 0 || (z[1]-z[3])%2 != 0 {
+		return z, false
+	}
+	// z/sqrt(2) = z*(w - w^3)/2.
+	m := z.mulSqrt2()
+	return ZOmega{m[0] / 2, m[1] / 2, m[2] / 2, m[3] / 2}, true
+}
+
+// A DOmega is an element of the ring Z[1/sqrt(2), i],
+// represented as Num/sqrt(2)^Exp.
+//
+// The results of arithmetic are reduced so that Exp is
+// as small as possible without becoming negative.
+type DOmega struct {
+	Num ZOmega
+	Exp int
+}
+
+func (d DOmega) Add(other DOmega) DOmega {
+	x, y := d, other
+	for x.Exp < y.Exp {
+		x.Num = x.Num.mulSqrt2()
+		x.Exp++
+	}
+	for y.Exp < x.Exp {
+		y.Num = y.Num.mulSqrt2()
+		y.Exp++
+	}
+	return DOmega{Num: x.Num.Add(y.Num), Exp: x.Exp}.reduce()
+}
+
+func (d DOmega) Mul(other DOmega) DOmega {
+	return DOmega{Num: d.Num.Mul(other.Num), Exp: d.Exp + other.Exp}.reduce()
+}
+
+func (d DOmega) Conj() DOmega {
+	return DOmega{Num: d.Num.Conj(), Exp: d.Exp}
+}
+
+func (d DOmega) Complex() complex128 {
+	return d.Num.Complex() / complex(math.Pow(math.Sqrt2, float64(d.Exp)), 0)
+}
+
+func (d DOmega) reduce() DOmega {
+	if d.Num == (ZOmega{}) {
+		return DOmega{}
+	}
+	for d.Exp > 0 {
+		num, ok := d.Num.divSqrt2()
+		if !ok {
+			break
+		}
+		d.Num = num
+		d.Exp--
+	}
+	return d
+}
+
+// An ExactMatrix2 is a 2x2 matrix with entries in the
+// ring Z[1/sqrt(2), i], such as the unitary of a circuit
+// made of H and T gates.
+type ExactMatrix2 struct {
+	M11 DOmega
+	M12 DOmega
+	M21 DOmega
+	M22 DOmega
+}
+
+// NewExactMatrix2 creates the identity.
+func NewExactMatrix2() ExactMatrix2 {
+	one := DOmega{Num: ZOmega{1}}
+	return ExactMatrix2{M11: one, M22: one}
+}
+
+// ExactCircuitMatrix computes the unitary of a circuit
+// on qubit 0 made of H, S, T, X, Y, and Z gates.
+func ExactCircuitMatrix(c Circuit) (ExactMatrix2, error) {
+	res := NewExactMatrix2()
+	for _, g := range c {
+		if sub, ok := g.(Circuit); ok {
+			m, err := ExactCircuitMatrix(sub)
+			if err != nil {
+				return res, err
+			}
+			m.Mul(&res)
+			res = m
+			continue
+		}
+		m, ok := exactGateMatrix(g)
+		if !ok {
+			return res, fmt.Errorf("exact matrix: unsupported gate %s", g)
+		}
+		m.Mul(&res)
+		res = m
+	}
+	return res, nil
+}
+
+// ExactMatrix2Approx finds the ExactMatrix2 which is
+// equal to m, up to rounding error.
+//
+// An error is returned if m is not a unitary with
+// entries in Z[1/sqrt(2), i], or if the denominators of
+// its entries are too large to be recovered from
+// floating-point values.
+func ExactMatrix2Approx(m *Matrix2) (ExactMatrix2, error) {
+	entries := []complex128{m.M11, m.M12, m.M21, m.M22}
+	for exp := 0; exp <= maxExactExp; exp++ {
+		var nums [4]ZOmega
+		found := true
+		for i, x := range entries {
+			nums[i], found = roundZOmega(x, exp)
+			if !found {
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		res := ExactMatrix2{
+			M11: DOmega{Num: nums[0], Exp: exp}.reduce(),
+			M12: DOmega{Num: nums[1], Exp: exp}.reduce(),
+			M21: DOmega{Num: nums[2], Exp: exp}.reduce(),
+			M22: DOmega{Num: nums[3], Exp: exp}.reduce(),
+		}
+		if !res.IsUnitary() {
+			break
+		}
+		return res, nil
+	}
+	return ExactMatrix2{}, errors.New("exact matrix: no unitary over Z[1/sqrt(2), i] found")
+}
+
+func (e *ExactMatrix2) Mul(other *ExactMatrix2) {
+	e.M11, e.M12, e.M21, e.M22 = e.M11.Mul(other.M11).Add(e.M12.Mul(other.M21)),
+		e.M11.Mul(other.M12).Add(e.M12.Mul(other.M22)),
+		e.M21.Mul(other.M11).Add(e.M22.Mul(other.M21)),
+		e.M21.Mul(other.M12).Add(e.M22.Mul(other.M22))
+}
+
+func (e *ExactMatrix2) ConjTranspose() {
+	e.M11, e.M12, e.M21, e.M22 = e.M11.Conj(), e.M21.Conj(), e.M12.Conj(), e.M22.Conj()
+}
+
+// IsUnitary checks if the matrix is exactly unitary.
+func (e *ExactMatrix2) IsUnitary() bool {
+	product := *e
+	product.ConjTranspose()
+	product.Mul(e)
+	return product == NewExactMatrix2()
+}
+
+// Matrix2 converts the matrix to floating-point.
+func (e *ExactMatrix2) Matrix2() Matrix2 {
+	return Matrix2{e.M11.Complex(), e.M12.Complex(), e.M21.Complex(), e.M22.Complex()}
+}
+
+// TCount computes the minimum number of T gates needed to
+// implement the unitary with H, S, and T gates, up to
+// global phase.
+func (e *ExactMatrix2) TCount() int {
+	return blochExp(e)
+}
+
+// Synthesize creates a circuit on qubit 0 that applies
+// the unitary up to global phase, using the fewest
+// possible T gates.
+//
+// The circuit is not made of HGates and TGates alone: it
+// also contains SGates and ZGates (and conjugated S and
+// T gates), since these Clifford gates do not count
+// towards the T-count. Writing each S as two T gates
+// would give an H and T circuit, but with a higher
+// T-count.
+//
+// The unitary must be exactly unitary (see IsUnitary).
+//
+// This uses the exact synthesis algorithm of Kliuchnikov,
+// Maslov, and Mosca in the form of the Matsumoto-Amano
+// normal form: while the Bloch sphere rotation of the
+// unitary has a denominator exponent k > 0, exactly one
+// T rotation (around X, Y, or Z) reduces k by one.
+// Once k = 0, the remainder is a Clifford gate.
+func (e *ExactMatrix2) Synthesize() Circuit {
+	if !e.IsUnitary() {
+		panic("matrix is not unitary")
+	}
+	syllables := []Circuit{
+		{&TGate{}},
+		{&HGate{}, &TGate{}, &HGate{}},
+		{&SGate{Conjugate: true}, &HGate{}, &TGate{}, &HGate{}, &SGate{}},
+	}
+	syllableInverses := make([]ExactMatrix2, len(syllables))
+	for i, s := range syllables {
+		syllableInverses[i], _ = ExactCircuitMatrix(s.Inverse().(Circuit))
+	}
+
+	// The unitary is u = s_1*s_2*...*s_k*c, where s_i are
+	// the syllables we peel off.
+	var peeled []Circuit
+	u := *e
+	for exp := blochExp(&u); exp > 0; exp-- {
+		found := false
+		for i, inv := range syllableInverses {
+			next := inv
+			next.Mul(&u)
+			if blochExp(&next) == exp-1 {
+				peeled = append(peeled, syllables[i])
+				u = next
+				found = true
+				break
+			}
+		}
+		if !found {
+			panic("exact synthesis failed to reduce denominator")
+		}
+	}
+
+	res := Circuit{cliffordCircuit(&u)}
+	for i := len(peeled) - 1; i >= 0; i-- {
+		res = append(res, peeled[i])
+	}
+	return simplifyCliffordT(res, false)
+}
+
+// ExactSynthesize converts a Matrix2 to an ExactMatrix2
+// and synthesizes it with ExactMatrix2.Synthesize.
+//
+// Like Synthesize, the result may contain S and Z gates
+// in addition to H and T gates.
+func ExactSynthesize(m *Matrix2) (Circuit, error) {
+	e, err := ExactMatrix2Approx(m)
+	if err != nil {
+		return nil, err
+	}
+	return e.Synthesize(), nil
+}
+
+func exactGateMatrix(g Gate) (ExactMatrix2, bool) {
+	g = toPhaseGate(g)
+	if singleGateBit(g) != 0 {
+		return ExactMatrix2{}, false
+	}
+	one := DOmega{Num: ZOmega{1}}
+	diag := func(power int) ExactMatrix2 {
+		var phase ZOmega
+		if power < 4 {
+			phase[power] = 1
+		} else {
+			phase[power-4] = -1
+		}
+		return ExactMatrix2{M11: one, M22: DOmega{Num: phase}}
+	}
+	switch g := g.(type) {
+	case *HGate:
+		h := DOmega{Num: ZOmega{1}, Exp: 1}
+		return ExactMatrix2{M11: h, M12: h, M21: h, M22: DOmega{Num: ZOmega{-1}, Exp: 1}}, true
+	case *XGate:
+		return ExactMatrix2{M12: one, M21: one}, true
+	case *YGate:
+		return ExactMatrix2{M12: DOmega{Num: ZOmega{0, 0, -1}}, M21: DOmega{Num: ZOmega{0, 0, 1}}},
+			true
+	case *phaseGate:
+		return diag(g.Eighths), true
+	}
+	return ExactMatrix2{}, false
+}
+
+// roundZOmega finds x in Z[w] such that x/sqrt(2)^exp is
+// z, up to rounding error.
+func roundZOmega(z complex128, exp int) (ZOmega, bool) {
+	scale := math.Pow(math.Sqrt2, float64(exp))
+	// For x = a + b*w + c*w^2 + d*w^3, the real part is
+	// a + (b-d)/sqrt(2) and the imaginary part is
+	// c + (b+d)/sqrt(2).
+	a, p, ok1 := roundZSqrt2(real(z)*scale, exp)
+	c, q, ok2 := roundZSqrt2(imag(z)*scale, exp)
+	if !ok1 || !ok2 || (p-q)%2 != 0 {
+		return ZOmega{}, false
+	}
+	return ZOmega{a, (p + q) / 2, c, (q - p) / 2}, true
+}
+
+// roundZSqrt2 finds integers a and p such that
+// a + p/sqrt(2) is x, for a number with denominator
+// exponent exp.
+func roundZSqrt2(x float64, exp int) (int64, int64, bool) {
+	tol := 1e-11 * math.Max(1, math.Abs(x))
+	// Both x and its conjugate a - p/sqrt(2) are bounded
+	// by sqrt(2)^exp for entries of a unitary.
+	bound := int64(math.Ceil(math.Pow(2, float64(exp+1)/2))) + 1
+	for p := int64(0); p <= bound; p++ {
+		for _, sp := range []int64{p, -p} {
+			a := math.Round(x - float64(sp)/math.Sqrt2)
+			if math.Abs(x-a-float64(sp)/math.Sqrt2) < tol {
+				return int64(a), sp, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// blochExp computes the smallest denominator exponent of
+// the Bloch sphere rotation of a unitary, which is in
+// Z[1/sqrt(2)].
+func blochExp(u *ExactMatrix2) int {
+	var res int
+	for _, row := range blochMatrix(u) {
+		for _, x := range row {
+			if x.Exp > res {
+				res = x.Exp
+			}
+		}
+	}
+	return res
+}
+
+// blochMatrix computes the SO(3) matrix with entries
+// tr(P_i*u*P_j*u^H)/2 for Paulis P_i and P_j.
+func blochMatrix(u *ExactMatrix2) [3][3]DOmega {
+	i := DOmega{Num: ZOmega{0, 0, 1}}
+	one := DOmega{Num: ZOmega{1}}
+	paulis := []ExactMatrix2{
+		{M12: one, M21: one},
+		{M12: DOmega{Num: i.Num.Neg()}, M21: i},
+		{M11: one, M22: DOmega{Num: ZOmega{-1}}},
+	}
+	uInv := *u
+	uInv.ConjTranspose()
+	var res [3][3]DOmega
+	for row := range paulis {
+		for col := range paulis {
+			product := paulis[row]
+			product.Mul(u)
+			product.Mul(&paulis[col])
+			product.Mul(&uInv)
+			trace := product.M11.Add(product.M22)
+			res[row][col] = trace.Mul(DOmega{Num: ZOmega{1}, Exp: 2})
+		}
+	}
+	return res
+}
+
+// cliffordCircuit finds a circuit of H and S gates for a
+// unitary whose Bloch sphere rotation is a signed
+// permutation.
+func cliffordCircuit(u *ExactMatrix2) Circuit {
+	key := fmt.Sprint(blochMatrix(u))
+	seen := map[string]bool{}
+	queue := []Circuit{{}}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		m, _ := ExactCircuitMatrix(c)
+		k := fmt.Sprint(blochMatrix(&m))
+		if k == key {
+			return c
+		}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		for _, g := range []Gate{&HGate{}, &SGate{}} {
+			queue = append(queue, append(append(Circuit{}, c...), g))
+		}
+	}
+	panic("unitary is not a Clifford gate")
+}
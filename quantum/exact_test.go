@@ -0,0 +1,156 @@
+package quantum
+
+import (
+	"fmt"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+func TestZOmegaArithmetic(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		var x, y ZOmega
+		for j := range x {
+			x[j] = rand.Int63n(21) - 10
+			y[j] = rand.Int63n(21) - 10
+		}
+		if cmplx.Abs(x.Mul(y).Complex()-x.Complex()*y.Complex()) > 1e-8 {
+			t.Fatalf("incorrect product of %v and %v", x, y)
+		}
+		if cmplx.Abs(x.Conj().Complex()-cmplx.Conj(x.Complex())) > 1e-8 {
+			t.Fatalf("incorrect conjugate of %v", x)
+		}
+		d1 := DOmega{Num: x, Exp: rand.Intn(4)}
+		d2 := DOmega{Num: y, Exp: rand.Intn(4)}
+		if cmplx.Abs(d1.Add(d2).Complex()-(d1.Complex()+d2.Complex())) > 1e-8 {
+			t.Fatalf("incorrect sum of %v and %v", d1, d2)
+		}
+		if cmplx.Abs(d1.Mul(d2).Complex()-d1.Complex()*d2.Complex()) > 1e-8 {
+			t.Fatalf("incorrect product of %v and %v", d1, d2)
+		}
+	}
+}
+
+func TestExactCircuitMatrix(t *testing.T) {
+	c := randomHTCircuit(30)
+	c = append(c, &XGate{}, &SGate{Conjugate: true}, &YGate{}, &ZGate{})
+	exact, err := ExactCircuitMatrix(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exact.IsUnitary() {
+		t.Error("matrix should be unitary")
+	}
+	actual := exact.Matrix2()
+	expected := circuitMatrix2(c)
+	if actual.PhaseDistance(&expected) > 1e-8 || cmplx.Abs(actual.M11-expected.M11) > 1e-8 {
+		t.Error("incorrect matrix")
+	}
+
+	approx, err := ExactMatrix2Approx(&expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approx != exact {
+		t.Error("approximate conversion does not match exact matrix")
+	}
+
+	if _, err := ExactCircuitMatrix(Circuit{&HGate{Bit: 1}}); err == nil {
+		t.Error("expected error for gate on qubit 1")
+	}
+	sqrtT := Matrix2{1, 0, 0, cmplx.Exp(complex(0, 3.141592653589793/8))}
+	if _, err := ExactMatrix2Approx(&sqrtT); err == nil {
+		t.Error("expected error for SqrtT")
+	}
+}
+
+func TestExactSynthesize(t *testing.T) {
+	for _, numGates := range []int{0, 1, 5, 20, 60} {
+		for i := 0; i < 5; i++ {
+			c := randomHTCircuit(numGates)
+			m := circuitMatrix2(c)
+			synth, err := ExactSynthesize(&m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := circuitMatrix2(synth)
+			if actual.PhaseDistance(&m) > 1e-8 {
+				t.Fatalf("incorrect synthesis of %s: %s", c, synth)
+			}
+			var tCount int
+			for _, g := range synth {
+				switch g.(type) {
+				case *TGate:
+					tCount++
+				case *HGate, *SGate, *ZGate:
+				default:
+					t.Fatalf("unexpected gate %s", g)
+				}
+			}
+			exact, _ := ExactCircuitMatrix(c)
+			if tCount != exact.TCount() {
+				t.Errorf("T-count %d does not match %d", tCount, exact.TCount())
+			}
+			if tCount > countTGates(c) {
+				t.Errorf("T-count %d exceeds original %d", tCount, countTGates(c))
+			}
+		}
+	}
+}
+
+func TestExactSynthesizeOptimal(t *testing.T) {
+	// Compare with the minimum T-count over all short
+	// circuits of H, S, T, and T* gates.
+	minTCounts := map[string]int{}
+	var search func(c Circuit, depth int)
+	search = func(c Circuit, depth int) {
+		exact, _ := ExactCircuitMatrix(c)
+		key := exactPhaseKey(&exact)
+		if old, ok := minTCounts[key]; !ok || countTGates(c) < old {
+			minTCounts[key] = countTGates(c)
+		}
+		if depth == 0 {
+			return
+		}
+		for _, g := range []Gate{&HGate{}, &SGate{}, &TGate{}, &TGate{Conjugate: true}} {
+			search(append(append(Circuit{}, c...), g), depth-1)
+		}
+	}
+	search(Circuit{}, 7)
+
+	for i := 0; i < 50; i++ {
+		c := randomHTCircuit(7)
+		exact, _ := ExactCircuitMatrix(c)
+		expected := minTCounts[exactPhaseKey(&exact)]
+		if actual := countTGates(exact.Synthesize()); actual > expected {
+			t.Errorf("circuit %s: expected T-count %d but got %d", c, expected, actual)
+		}
+	}
+}
+
+func randomHTCircuit(numGates int) Circuit {
+	var res Circuit
+	for i := 0; i < numGates; i++ {
+		if rand.Intn(2) == 0 {
+			res = append(res, &HGate{})
+		} else {
+			res = append(res, &TGate{Conjugate: rand.Intn(2) == 0})
+		}
+	}
+	return res
+}
+
+func countTGates(c Circuit) int {
+	var res int
+	for _, g := range c {
+		if _, ok := g.(*TGate); ok {
+			res++
+		}
+	}
+	return res
+}
+
+// exactPhaseKey identifies a unitary up to global phase.
+func exactPhaseKey(e *ExactMatrix2) string {
+	return fmt.Sprint(blochMatrix(e))
+}
@@ -0,0 +1,232 @@
+package quantum
+
+import "math/cmplx"
+
+// DefaultFusionBits is the default maximum number of
+// qubits that a FusedSimulation combines into one gate.
+const DefaultFusionBits = 3
+
+// The supported range for the number of qubits in a fused
+// unitary. Two-qubit gates must fit in one unitary, and
+// larger unitaries cost more to build than they save.
+const (
+	minFusionBits = 2
+	maxFusionBits = 5
+)
+
+// fusionZero is the magnitude below which entries of a
+// fused unitary are treated as zero.
+const fusionZero = 1e-14
+
+// A FusedSimulation is a Computer that buffers gates on
+// a small set of qubits and multiplies them into a single
+// dense unitary. This unitary is applied to the
+// underlying Simulation in one sweep, rather than
+// sweeping over the state once per gate.
+//
+// Gates are buffered until a gate touches too many
+// qubits, a qubit is measured, or Flush is called.
+// Callers must Flush before reading Simulation.Phases.
+type FusedSimulation struct {
+	Simulation *Simulation
+
+	// MaxBits is the maximum number of qubits for a fused
+	// unitary, from 2 to 5.
+	MaxBits int
+
+	bits   []int
+	matrix []complex128
+}
+
+// NewFusedSimulation creates a FusedSimulation that
+// fuses gates on up to maxBits qubits.
+//
+// If maxBits is 0, DefaultFusionBits is used. Otherwise,
+// maxBits must be between 2 and 5.
+func NewFusedSimulation(s *Simulation, maxBits int) *FusedSimulation {
+	if maxBits == 0 {
+		maxBits = DefaultFusionBits
+	} else if maxBits < minFusionBits || maxBits > maxFusionBits {
+		panic("fusion size must be between 2 and 5 qubits")
+	}
+	return &FusedSimulation{Simulation: s, MaxBits: maxBits}
+}
+
+// ApplyFused applies the gates from a to the simulation,
+// fusing gates on up to maxBits qubits at once.
+//
+// If maxBits is 0, DefaultFusionBits is used. Otherwise,
+// maxBits must be between 2 and 5.
+func (s *Simulation) ApplyFused(a Applier, maxBits int) {
+	f := NewFusedSimulation(s, maxBits)
+	a.Apply(f)
+	f.Flush()
+}
+
+func (f *FusedSimulation) NumBits() int {
+	return f.Simulation.NumBits()
+}
+
+func (f *FusedSimulation) InUse(bit int) bool {
+	return f.Simulation.InUse(bit)
+}
+
+func (f *FusedSimulation) Measure(bitIdx int) bool {
+	f.Flush()
+	return f.Simulation.Measure(bitIdx)
+}
+
+func (f *FusedSimulation) Unitary(target int, m *Matrix2) {
+	if target < 0 || target >= f.NumBits() {
+		panic("bit index out of range")
+	}
+	local := f.localBits(target)[0]
+	dim := 1 << uint(len(f.bits))
+	mask := 1 << uint(local)
+	for r := 0; r < dim; r++ {
+		if r&mask != 0 {
+			continue
+		}
+		row0 := f.matrix[r*dim : (r+1)*dim]
+		row1 := f.matrix[(r|mask)*dim : ((r|mask)+1)*dim]
+		for c, p0 := range row0 {
+			p1 := row1[c]
+			row0[c] = m.M11*p0 + m.M12*p1
+			row1[c] = m.M21*p0 + m.M22*p1
+		}
+	}
+}
+
+func (f *FusedSimulation) CNot(control, target int) {
+	if control < 0 || control >= f.NumBits() || target < 0 || target >= f.NumBits() {
+		panic("bit index out of range")
+	}
+	if control == target {
+		panic("overlapping control and target is not invertible")
+	}
+	local := f.localBits(control, target)
+	dim := 1 << uint(len(f.bits))
+	controlMask := 1 << uint(local[0])
+	targetMask := 1 << uint(local[1])
+	for r := 0; r < dim; r++ {
+		if r&controlMask != 0 && r&targetMask == 0 {
+			row0 := f.matrix[r*dim : (r+1)*dim]
+			row1 := f.matrix[(r|targetMask)*dim : ((r|targetMask)+1)*dim]
+			for c := range row0 {
+				row0[c], row1[c] = row1[c], row0[c]
+			}
+		}
+	}
+}
+
+// Flush applies all of the buffered gates to the
+// underlying Simulation.
+func (f *FusedSimulation) Flush() {
+	if len(f.bits) == 0 {
+		return
+	}
+	dim := 1 << uint(len(f.bits))
+	var blockMask int
+	offsets := make([]int, dim)
+	for i, bit := range f.bits {
+		blockMask |= 1 << uint(bit)
+		for j := range offsets {
+			if j&(1<<uint(i)) != 0 {
+				offsets[j] |= 1 << uint(bit)
+			}
+		}
+	}
+
+	// Fused gates are often nearly permutations, so we
+	// only multiply by the non-zero entries of each row.
+	rows := make([][]fusedEntry, dim)
+	for r := range rows {
+		for c, x := range f.matrix[r*dim : (r+1)*dim] {
+			if cmplx.Abs(x) > fusionZero {
+				rows[r] = append(rows[r], fusedEntry{col: c, value: x})
+			}
+		}
+	}
+
+	s := f.Simulation
+	s.parallelFor(func(start, end int) {
+		input := make([]complex128, dim)
+		for i := start; i < end; i++ {
+			if i&blockMask != 0 {
+				continue
+			}
+			for j, offset := range offsets {
+				input[j] = s.Phases[i|offset]
+			}
+			for r, offset := range offsets {
+				var sum complex128
+				for _, entry := range rows[r] {
+					sum += entry.value * input[entry.col]
+				}
+				s.Phases[i|offset] = sum
+			}
+		}
+	})
+
+	f.bits = nil
+	f.matrix = nil
+}
+
+// localBits finds the indices of qubits in the buffered
+// unitary, adding qubits to it and flushing it as
+// needed.
+func (f *FusedSimulation) localBits(bits ...int) []int {
+	var missing int
+	for _, bit := range bits {
+		if f.localIndex(bit) == -1 {
+			missing++
+		}
+	}
+	if len(f.bits)+missing > f.MaxBits && len(f.bits) > 0 {
+		f.Flush()
+	}
+	if len(f.bits) == 0 {
+		f.matrix = []complex128{1}
+	}
+	var res []int
+	for _, bit := range bits {
+		idx := f.localIndex(bit)
+		if idx == -1 {
+			f.addBit(bit)
+			idx = len(f.bits) - 1
+		}
+		res = append(res, idx)
+	}
+	return res
+}
+
+func (f *FusedSimulation) localIndex(bit int) int {
+	for i, b := range f.bits {
+		if b == bit {
+			return i
+		}
+	}
+	return -1
+}
+
+// addBit extends the buffered unitary to act on a new
+// qubit, which becomes its most significant local bit.
+func (f *FusedSimulation) addBit(bit int) {
+	oldDim := 1 << uint(len(f.bits))
+	newDim := oldDim * 2
+	newMatrix := make([]complex128, newDim*newDim)
+	for r := 0; r < oldDim; r++ {
+		for c := 0; c < oldDim; c++ {
+			x := f.matrix[r*oldDim+c]
+			newMatrix[r*newDim+c] = x
+			newMatrix[(r+oldDim)*newDim+c+oldDim] = x
+		}
+	}
+	f.bits = append(f.bits, bit)
+	f.matrix = newMatrix
+}
+
+type fusedEntry struct {
+	col   int
+	value complex128
+}
@@ -0,0 +1,93 @@
+package quantum
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestFusedSimulation(t *testing.T) {
+	for _, maxBits := range []int{2, 3, 4, 5} {
+		t.Run(fmt.Sprintf("Bits%d", maxBits), func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				s1 := RandomSimulation(8)
+				s2 := s1.Copy()
+				circuit := randomizedCircuit(rand.Perm(8), rand.Int(), 30)
+				circuit.Apply(s1)
+				s2.ApplyFused(circuit, maxBits)
+				if !s1.ApproxEqual(s2, 1e-8) {
+					t.Fatal("incorrect result")
+				}
+			}
+		})
+	}
+}
+
+func TestFusedSimulationMaxBits(t *testing.T) {
+	for _, maxBits := range []int{-1, 1, 6} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for %d bits", maxBits)
+				}
+			}()
+			NewFusedSimulation(NewSimulation(3), maxBits)
+		}()
+	}
+}
+
+func TestFusedSimulationToffoliN(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		numBits := rand.Intn(6) + 5
+		perm := rand.Perm(numBits)
+		target, control := perm[0], perm[1:numBits-2]
+		s := RandomSimulation(numBits)
+		expected := rawToffoliN(s, target, control)
+		s.ApplyFused(FnApplier(func(c Computer) {
+			ToffoliN(c, target, control...)
+		}), 0)
+		if !expected.ApproxEqual(s, 1e-8) {
+			t.Fatalf("error for %d bits with target %d and control %v", numBits, target, control)
+		}
+	}
+}
+
+func TestFusedSimulationModAdd(t *testing.T) {
+	s1 := RandomSimulation(10)
+	s2 := s1.Copy()
+	f := NewFusedSimulation(s2, 4)
+	for _, c := range []Computer{s1, f} {
+		ModAdd(c, Reg{0, 1, 2}, Reg{3, 4, 5}, Reg{6, 7, 8}, 9)
+	}
+	f.Flush()
+	if !s1.ApproxEqual(s2, 1e-8) {
+		t.Fatal("incorrect result")
+	}
+}
+
+func BenchmarkFusedSimulation(b *testing.B) {
+	toffoli := FnApplier(func(c Computer) {
+		ToffoliN(c, 0, 1, 2, 3, 4, 5, 6)
+	})
+	modAdd := FnApplier(func(c Computer) {
+		ModAdd(c, Reg{0, 1, 2, 3, 4}, Reg{5, 6, 7, 8, 9}, Reg{10, 11, 12, 13, 14}, 15)
+	})
+	for _, name := range []string{"ToffoliN", "ModAdd"} {
+		applier := toffoli
+		if name == "ModAdd" {
+			applier = modAdd
+		}
+		b.Run(name+"Plain", func(b *testing.B) {
+			s := RandomSimulation(16)
+			for i := 0; i < b.N; i++ {
+				applier.Apply(s)
+			}
+		})
+		b.Run(name+"Fused", func(b *testing.B) {
+			s := RandomSimulation(16)
+			for i := 0; i < b.N; i++ {
+				s.ApplyFused(applier, 0)
+			}
+		})
+	}
+}
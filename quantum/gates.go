@@ -113,6 +113,31 @@ func (t *TGate) Inverse() Gate {
 	}
 }
 
+type SGate struct {
+	Bit       int
+	Conjugate bool
+}
+
+func (s *SGate) String() string {
+	conjStr := ""
+	if s.Conjugate {
+		conjStr = "*"
+	}
+	return "S" + conjStr + "(" + strconv.Itoa(s.Bit) + ")"
+}
+
+func (s *SGate) Apply(c Computer) {
+	if s.Conjugate {
+		InvS(c, s.Bit)
+	} else {
+		S(c, s.Bit)
+	}
+}
+
+func (s *SGate) Inverse() Gate {
+	return &SGate{Bit: s.Bit, Conjugate: !s.Conjugate}
+}
+
 type XGate struct {
 	Bit int
 }
@@ -161,6 +186,32 @@ func (z *ZGate) Inverse() Gate {
 	return z
 }
 
+// A UnitaryGate applies an arbitrary single-qubit
+// unitary.
+type UnitaryGate struct {
+	Bit    int
+	Matrix Matrix2
+}
+
+func (u *UnitaryGate) String() string {
+	var entries []string
+	for _, x := range []complex128{u.Matrix.M11, u.Matrix.M12, u.Matrix.M21, u.Matrix.M22} {
+		entries = append(entries, strconv.FormatComplex(x, 'g', -1, 128))
+	}
+	return fmt.Sprintf("U(%d, %s)", u.Bit, strings.Join(entries, ", "))
+}
+
+func (u *UnitaryGate) Apply(c Computer) {
+	m := u.Matrix
+	c.Unitary(u.Bit, &m)
+}
+
+func (u *UnitaryGate) Inverse() Gate {
+	m := u.Matrix
+	m.ConjTranspose()
+	return &UnitaryGate{Bit: u.Bit, Matrix: m}
+}
+
 type CNotGate struct {
 	Control int
 	Target  int
@@ -314,6 +365,23 @@ func (c *CSwapGate) Inverse() Gate {
 	return c
 }
 
+// A MeasureGate measures a qubit, discarding the result.
+type MeasureGate struct {
+	Bit int
+}
+
+func (m *MeasureGate) String() string {
+	return "Measure(" + strconv.Itoa(m.Bit) + ")"
+}
+
+func (m *MeasureGate) Apply(c Computer) {
+	c.Measure(m.Bit)
+}
+
+func (m *MeasureGate) Inverse() Gate {
+	panic("measurement is not invertible")
+}
+
 // FnGate is a gate that calls contained functions.
 type FnGate struct {
 	Forward  func(c Computer)
@@ -336,6 +404,10 @@ func (f *FnGate) Inverse() Gate {
 // A ClassicalGate applies a bitwise function to classical
 // bases states.
 // It can only be applied to *Simulator computers.
+//
+// F must be a permutation, and it must be safe to call
+// from multiple goroutines at once, since large
+// simulations evaluate it in parallel.
 type ClassicalGate struct {
 	F        func(b []bool) []bool
 	Inverted bool
@@ -355,9 +427,12 @@ func NewClassicalGate(F func(b []bool) []bool, str string) *ClassicalGate {
 func (c *ClassicalGate) Apply(qc Computer) {
 	s := qc.(*Simulation)
 	s1 := s.Copy()
-	if c.Inverted {
-		for i := range s1.Phases {
-			input := make([]bool, s.NumBits())
+
+	// Since F is a permutation, every output index is
+	// written by exactly one shard.
+	s.parallelFor(func(start, end int) {
+		input := make([]bool, s.NumBits())
+		for i := start; i < end; i++ {
 			for j := range input {
 				input[j] = (i&(1<<uint(j)) != 0)
 			}
@@ -368,24 +443,13 @@ func (c *ClassicalGate) Apply(qc Computer) {
 					outIdx |= 1 << uint(j)
 				}
 			}
-			s.Phases[i] = s1.Phases[outIdx]
-		}
-	} else {
-		for i, phase := range s1.Phases {
-			input := make([]bool, s.NumBits())
-			for j := range input {
-				input[j] = (i&(1<<uint(j)) != 0)
-			}
-			output := c.F(input)
-			outIdx := 0
-			for j, b := range output {
-				if b {
-					outIdx |= 1 << uint(j)
-				}
+			if c.Inverted {
+				s.Phases[i] = s1.Phases[outIdx]
+			} else {
+				s.Phases[outIdx] = s1.Phases[i]
 			}
-			s.Phases[outIdx] = phase
 		}
-	}
+	})
 }
 
 func (c *ClassicalGate) Inverse() Gate {
@@ -0,0 +1,229 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// A GradientFunc computes the gradient of an expectation
+// value with respect to the free parameters of a circuit,
+// such as ParamShiftGradient or AdjointGradient.
+type GradientFunc func(numBits int, c Circuit, o Observable,
+	values map[string]float64) map[string]float64
+
+// ParamShiftGradient computes the gradient of an
+// expectation value with respect to each free parameter
+// of a circuit, using the parameter-shift rule.
+//
+// The circuit is applied to the all-zero state, and
+// values must contain every free parameter.
+//
+// Each occurrence of a parameter is shifted separately,
+// so the cost is a few circuit evaluations per
+// occurrence. This only relies on running the circuit,
+// making it suitable for hardware and noisy simulators;
+// for exact simulation, AdjointGradient is faster.
+//
+// Controlled rotations are supported using a four-term
+// shift rule.
+func ParamShiftGradient(numBits int, c Circuit, o Observable,
+	values map[string]float64) map[string]float64 {
+	ops := flattenParamOps(c, values, nil)
+	grad := zeroGradient(c)
+	prefix := NewSimulation(numBits)
+	for k, op := range ops {
+		if op.Param != nil {
+			evaluate := func(j int, shift float64) float64 {
+				s := prefix.Copy()
+				angles := append([]float64{}, op.Angles...)
+				angles[j] += shift
+				controlledRotation(op, angles).Apply(s)
+				for _, rest := range ops[k+1:] {
+					rest.Gate.Apply(s)
+				}
+				return Expectation(o, s)
+			}
+			for j, angle := range op.Param.Angles {
+				if len(angle.Coeffs) == 0 {
+					continue
+				}
+				var deriv float64
+				if len(op.Controls) == 0 {
+					deriv = (evaluate(j, math.Pi/2) - evaluate(j, -math.Pi/2)) / 2
+				} else {
+					dPlus := (math.Sqrt2 + 1) / (4 * math.Sqrt2)
+					dMinus := (math.Sqrt2 - 1) / (4 * math.Sqrt2)
+					deriv = dPlus*(evaluate(j, math.Pi/2)-evaluate(j, -math.Pi/2)) -
+						dMinus*(evaluate(j, 3*math.Pi/2)-evaluate(j, -3*math.Pi/2))
+				}
+				for name, coeff := range angle.Coeffs {
+					grad[name] += coeff * deriv
+				}
+			}
+		}
+		op.Gate.Apply(prefix)
+	}
+	return grad
+}
+
+// AdjointGradient computes the same gradient as
+// ParamShiftGradient, but uses adjoint differentiation on
+// a Simulation.
+//
+// The cost is roughly three circuit evaluations,
+// regardless of the number of parameters. Every gate in
+// the circuit must be invertible.
+func AdjointGradient(numBits int, c Circuit, o Observable,
+	values map[string]float64) map[string]float64 {
+	_, grad := adjointGradient(numBits, c, o, values)
+	return grad
+}
+
+func adjointGradient(numBits int, c Circuit, o Observable,
+	values map[string]float64) (float64, map[string]float64) {
+	ops := flattenParamOps(c, values, nil)
+	grad := zeroGradient(c)
+
+	psi := NewSimulation(numBits)
+	for _, op := range ops {
+		op.Gate.Apply(psi)
+	}
+	lambda := NewSimulation(numBits)
+	lambda.Phases = o.Mul(psi.Phases)
+	expectation := innerProductReal(psi.Phases, lambda.Phases)
+
+	for k := len(ops) - 1; k >= 0; k-- {
+		op := ops[k]
+		inv := op.Gate.Inverse()
+		inv.Apply(psi)
+		if op.Param != nil {
+			for j, angle := range op.Param.Angles {
+				if len(angle.Coeffs) == 0 {
+					continue
+				}
+				m := rotationDerivative(op.Param.Kind, op.Angles, j)
+				mu := applyControlledMatrix(psi.Phases, op.Controls, op.Param.Bit, &m)
+				deriv := 2 * innerProductReal(lambda.Phases, mu)
+				for name, coeff := range angle.Coeffs {
+					grad[name] += coeff * deriv
+				}
+			}
+		}
+		inv.Apply(lambda)
+	}
+	return expectation, grad
+}
+
+// paramOp is a gate in a flattened circuit. If the gate
+// is a ParamGate with free parameters, Param is the
+// original gate and Angles are its bound angles.
+type paramOp struct {
+	Gate     Gate
+	Controls []int
+	Param    *ParamGate
+	Angles   []float64
+}
+
+// flattenParamOps expands Circuits and ControlledGates
+// which contain free parameters, so that each ParamGate
+// becomes its own operation.
+func flattenParamOps(g Gate, values map[string]float64, controls []int) []paramOp {
+	if len(gateFreeParams(g)) == 0 {
+		return []paramOp{{Gate: wrapControls(g, controls)}}
+	}
+	switch g := g.(type) {
+	case Circuit:
+		var res []paramOp
+		for _, sub := range g {
+			res = append(res, flattenParamOps(sub, values, controls)...)
+		}
+		return res
+	case *ControlledGate:
+		subControls := append(append([]int{}, controls...), g.Control)
+		return flattenParamOps(g.Gate, values, subControls)
+	case *ParamGate:
+		angles := make([]float64, len(g.Angles))
+		for i, angle := range g.Angles {
+			value, ok := angle.Bind(values).Value()
+			if !ok {
+				panic("unbound parameter: " + angle.Bind(values).Symbols()[0])
+			}
+			angles[i] = value
+		}
+		op := paramOp{Controls: controls, Param: g, Angles: angles}
+		op.Gate = controlledRotation(op, angles)
+		return []paramOp{op}
+	}
+	panic("unexpected gate with parameters")
+}
+
+func controlledRotation(op paramOp, angles []float64) Gate {
+	return wrapControls(rotationGate(op.Param.Kind, op.Param.Bit, angles), op.Controls)
+}
+
+func wrapControls(g Gate, controls []int) Gate {
+	for i := len(controls) - 1; i >= 0; i-- {
+		g = &ControlledGate{Control: controls[i], Gate: g}
+	}
+	return g
+}
+
+func zeroGradient(c Circuit) map[string]float64 {
+	grad := map[string]float64{}
+	for _, name := range c.FreeParams() {
+		grad[name] = 0
+	}
+	return grad
+}
+
+// rotationDerivative computes the derivative of a
+// rotation gate's matrix with respect to one angle.
+func rotationDerivative(kind string, angles []float64, j int) Matrix2 {
+	switch kind {
+	case "RX", "RY", "RZ":
+		// The derivative of exp(-i*theta*P/2) is
+		// -i*P/2*exp(-i*theta*P/2), and -i*P is a
+		// rotation by pi.
+		g := rotationGate(kind, 0, []float64{angles[0] + math.Pi})
+		m := g.(interface{ Matrix() Matrix2 }).Matrix()
+		return Matrix2{m.M11 / 2, m.M12 / 2, m.M21 / 2, m.M22 / 2}
+	case "Phase":
+		return Matrix2{0, 0, 0, 1i * cmplx.Exp(complex(0, angles[0]))}
+	}
+	theta, phi, lambda := angles[0], angles[1], angles[2]
+	cos := complex(math.Cos(theta/2), 0)
+	sin := complex(math.Sin(theta/2), 0)
+	ePhi := cmplx.Exp(complex(0, phi))
+	eLambda := cmplx.Exp(complex(0, lambda))
+	switch j {
+	case 0:
+		return Matrix2{-sin / 2, -eLambda * cos / 2, ePhi * cos / 2, -ePhi * eLambda * sin / 2}
+	case 1:
+		return Matrix2{0, 0, 1i * ePhi * sin, 1i * ePhi * eLambda * cos}
+	default:
+		return Matrix2{0, -1i * eLambda * sin, 0, 1i * ePhi * eLambda * cos}
+	}
+}
+
+// applyControlledMatrix computes the product of a state
+// vector and the operator which applies m to the target
+// when every control is set, and is zero elsewhere.
+func applyControlledMatrix(phases []complex128, controls []int, target int,
+	m *Matrix2) []complex128 {
+	var controlMask int
+	for _, c := range controls {
+		controlMask |= 1 << uint(c)
+	}
+	targetMask := 1 << uint(target)
+	res := make([]complex128, len(phases))
+	for i := range phases {
+		if i&targetMask != 0 || i&controlMask != controlMask {
+			continue
+		}
+		other := i | targetMask
+		p0, p1 := phases[i], phases[other]
+		res[i] = m.M11*p0 + m.M12*p1
+		res[other] = m.M21*p0 + m.M22*p1
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+package quantum
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestGradientSimple(t *testing.T) {
+	circuit := Circuit{
+		&ParamGate{Kind: "RY", Bit: 0, Angles: []Param{NewParam("theta")}},
+	}
+	obs := PauliSum{{Coeff: 1, Paulis: "Z"}}
+	values := map[string]float64{"theta": 0.7}
+	expected := -math.Sin(0.7)
+	for name, gradFn := range map[string]GradientFunc{
+		"ParamShift": ParamShiftGradient,
+		"Adjoint":    AdjointGradient,
+	} {
+		actual := gradFn(1, circuit, obs, values)["theta"]
+		if math.Abs(actual-expected) > 1e-8 {
+			t.Errorf("%s: expected %f but got %f", name, expected, actual)
+		}
+	}
+}
+
+func TestGradientRandom(t *testing.T) {
+	a, b, c := NewParam("a"), NewParam("b"), NewParam("c")
+	circuit := Circuit{
+		&HGate{Bit: 0},
+		&ParamGate{Kind: "RX", Bit: 1, Angles: []Param{a}},
+		&ParamGate{Kind: "RZ", Bit: 0, Angles: []Param{a.Scale(2).Add(b)}},
+		&CNotGate{Control: 0, Target: 2},
+		&ParamGate{Kind: "U3", Bit: 2, Angles: []Param{b, c, a.Scale(-0.5)}},
+		&ControlledGate{Control: 2, Gate: &ParamGate{Kind: "RY", Bit: 1, Angles: []Param{c}}},
+		&ControlledGate{Control: 1, Gate: Circuit{
+			&TGate{Bit: 0},
+			&ParamGate{Kind: "Phase", Bit: 0, Angles: []Param{b.Add(NewConstParam(1))}},
+			&ParamGate{Kind: "U3", Bit: 2, Angles: []Param{a, b, c}},
+		}},
+		&ParamGate{Kind: "RY", Bit: 0, Angles: []Param{NewConstParam(0.3)}},
+		&SqrtNotGate{Bit: 1},
+	}
+	obs := PauliSum{
+		{Coeff: 0.7, Paulis: "ZXI"},
+		{Coeff: -1.3, Paulis: "IYZ"},
+		{Coeff: 0.4, Paulis: "XIY"},
+	}
+	values := map[string]float64{
+		"a": rand.NormFloat64(),
+		"b": rand.NormFloat64(),
+		"c": rand.NormFloat64(),
+	}
+
+	expected := map[string]float64{}
+	for name := range values {
+		const eps = 1e-5
+		plus := copyValues(values)
+		plus[name] += eps
+		minus := copyValues(values)
+		minus[name] -= eps
+		expected[name] = (CircuitExpectation(3, circuit, obs, plus) -
+			CircuitExpectation(3, circuit, obs, minus)) / (2 * eps)
+	}
+
+	for name, gradFn := range map[string]GradientFunc{
+		"ParamShift": ParamShiftGradient,
+		"Adjoint":    AdjointGradient,
+	} {
+		actual := gradFn(3, circuit, obs, values)
+		if len(actual) != len(expected) {
+			t.Errorf("%s: unexpected gradient %v", name, actual)
+		}
+		for param, x := range expected {
+			if math.Abs(actual[param]-x) > 1e-5 {
+				t.Errorf("%s: param %s: expected %f but got %f", name, param, x, actual[param])
+			}
+		}
+	}
+}
+
+func copyValues(values map[string]float64) map[string]float64 {
+	res := map[string]float64{}
+	for k, v := range values {
+		res[k] = v
+	}
+	return res
+}
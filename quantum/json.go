@@ -0,0 +1,203 @@
+package quantum
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// CircuitJSONVersion is the version of the format
+// produced by MarshalCircuit.
+const CircuitJSONVersion = 1
+
+var (
+	gateRegistryLock sync.RWMutex
+	gateKinds        = map[string]reflect.Type{}
+	gateTypes        = map[reflect.Type]string{}
+)
+
+func init() {
+	RegisterGate("H", &HGate{})
+	RegisterGate("CH", &CHGate{})
+	RegisterGate("T", &TGate{})
+	RegisterGate("S", &SGate{})
+	RegisterGate("X", &XGate{})
+	RegisterGate("Y", &YGate{})
+	RegisterGate("Z", &ZGate{})
+	RegisterGate("U", &UnitaryGate{})
+	RegisterGate("CNot", &CNotGate{})
+	RegisterGate("CCNot", &CCNotGate{})
+	RegisterGate("SqrtNot", &SqrtNotGate{})
+	RegisterGate("CSqrtNot", &CSqrtNotGate{})
+	RegisterGate("SqrtT", &SqrtTGate{})
+	RegisterGate("Swap", &SwapGate{})
+	RegisterGate("CSwap", &CSwapGate{})
+	RegisterGate("Measure", &MeasureGate{})
+	RegisterGate("RX", &RXGate{})
+	RegisterGate("RY", &RYGate{})
+	RegisterGate("RZ", &RZGate{})
+	RegisterGate("Phase", &PhaseGate{})
+	RegisterGate("U3", &U3Gate{})
+	RegisterGate("Controlled", &ControlledGate{})
+	RegisterGate("Param", &ParamGate{})
+	RegisterGate("Circuit", Circuit{})
+}
+
+// RegisterGate makes a Gate type available to MarshalGate
+// and UnmarshalGate under the given kind.
+//
+// The proto is any value of the type, such as a pointer
+// to a zero struct. The type is encoded with
+// encoding/json, so it may implement json.Marshaler and
+// json.Unmarshaler. Fields which contain other gates
+// should be encoded with MarshalGate.
+func RegisterGate(kind string, proto Gate) {
+	gateRegistryLock.Lock()
+	defer gateRegistryLock.Unlock()
+	t := reflect.TypeOf(proto)
+	if _, ok := gateKinds[kind]; ok {
+		panic("gate kind already registered: " + kind)
+	}
+	if _, ok := gateTypes[t]; ok {
+		panic(fmt.Sprintf("gate type already registered: %v", t))
+	}
+	gateKinds[kind] = t
+	gateTypes[t] = kind
+}
+
+type gateJSON struct {
+	Kind string          `json:"kind"`
+	Gate json.RawMessage `json:"gate"`
+}
+
+// MarshalGate encodes a gate of a registered type as
+// JSON, including its kind.
+func MarshalGate(g Gate) ([]byte, error) {
+	gateRegistryLock.RLock()
+	kind, ok := gateTypes[reflect.TypeOf(g)]
+	gateRegistryLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("marshal gate: type %T is not registered", g)
+	}
+	data, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&gateJSON{Kind: kind, Gate: data})
+}
+
+// UnmarshalGate decodes a gate that was encoded with
+// MarshalGate.
+func UnmarshalGate(data []byte) (Gate, error) {
+	var obj gateJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	gateRegistryLock.RLock()
+	t, ok := gateKinds[obj.Kind]
+	gateRegistryLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unmarshal gate: unknown kind %q", obj.Kind)
+	}
+	var value reflect.Value
+	if t.Kind() == reflect.Ptr {
+		value = reflect.New(t.Elem())
+		if err := json.Unmarshal(obj.Gate, value.Interface()); err != nil {
+			return nil, err
+		}
+	} else {
+		ptr := reflect.New(t)
+		if err := json.Unmarshal(obj.Gate, ptr.Interface()); err != nil {
+			return nil, err
+		}
+		value = ptr.Elem()
+	}
+	return value.Interface().(Gate), nil
+}
+
+// MarshalJSON encodes the circuit as a list of gates in
+// the format of MarshalGate.
+func (c Circuit) MarshalJSON() ([]byte, error) {
+	gates := make([]json.RawMessage, len(c))
+	for i, g := range c {
+		data, err := MarshalGate(g)
+		if err != nil {
+			return nil, err
+		}
+		gates[i] = data
+	}
+	return json.Marshal(gates)
+}
+
+// UnmarshalJSON decodes a list of gates in the format of
+// MarshalGate.
+func (c *Circuit) UnmarshalJSON(data []byte) error {
+	var gates []json.RawMessage
+	if err := json.Unmarshal(data, &gates); err != nil {
+		return err
+	}
+	*c = make(Circuit, len(gates))
+	for i, gateData := range gates {
+		g, err := UnmarshalGate(gateData)
+		if err != nil {
+			return err
+		}
+		(*c)[i] = g
+	}
+	return nil
+}
+
+type circuitJSON struct {
+	Version int     `json:"version"`
+	NumBits int     `json:"num_bits"`
+	Gates   Circuit `json:"gates"`
+}
+
+// MarshalCircuit encodes a circuit on numBits qubits as a
+// versioned JSON object.
+func MarshalCircuit(numBits int, c Circuit) ([]byte, error) {
+	if c == nil {
+		c = Circuit{}
+	}
+	return json.Marshal(&circuitJSON{
+		Version: CircuitJSONVersion,
+		NumBits: numBits,
+		Gates:   c,
+	})
+}
+
+// UnmarshalCircuit decodes a circuit that was encoded
+// with MarshalCircuit, and returns the number of qubits.
+func UnmarshalCircuit(data []byte) (Circuit, int, error) {
+	var obj circuitJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, 0, err
+	}
+	if obj.Version != CircuitJSONVersion {
+		return nil, 0, fmt.Errorf("unmarshal circuit: unsupported version %d", obj.Version)
+	}
+	return obj.Gates, obj.NumBits, nil
+}
+
+// MarshalJSON encodes the matrix as a list of the
+// [real, imaginary] parts of M11, M12, M21, and M22.
+func (m Matrix2) MarshalJSON() ([]byte, error) {
+	var entries [4][2]float64
+	for i, x := range []complex128{m.M11, m.M12, m.M21, m.M22} {
+		entries[i] = [2]float64{real(x), imag(x)}
+	}
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON decodes the format from MarshalJSON.
+func (m *Matrix2) UnmarshalJSON(data []byte) error {
+	var entries [4][2]float64
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	for i, x := range []*complex128{&m.M11, &m.M12, &m.M21, &m.M22} {
+		*x = complex(entries[i][0], entries[i][1])
+	}
+	return nil
+}
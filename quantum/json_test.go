@@ -0,0 +1,65 @@
+package quantum
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestMarshalCircuit(t *testing.T) {
+	circuit := Circuit{
+		&HGate{Bit: 0},
+		&CHGate{Control: 1, Target: 2},
+		&TGate{Bit: 2, Conjugate: true},
+		&SGate{Bit: 1},
+		&CNotGate{Control: 3, Target: 0},
+		&CCNotGate{Control1: 0, Control2: 2, Target: 1},
+		&CSqrtNotGate{Control: 1, Target: 2, Invert: true},
+		&SqrtTGate{Bit: 3},
+		&CSwapGate{Control: 2, A: 3, B: 1},
+		&UnitaryGate{Bit: 1, Matrix: RandomMatrix2()},
+		Circuit{&XGate{Bit: 1}, &SwapGate{A: 0, B: 1}},
+		&MeasureGate{Bit: 2},
+	}
+	circuit = append(circuit, randomizedCircuit(rand.Perm(4), rand.Int(), 20)...)
+	data, err := MarshalCircuit(4, circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, numBits, err := UnmarshalCircuit(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if numBits != 4 {
+		t.Errorf("unexpected number of bits: %d", numBits)
+	}
+	if decoded.String() != circuit.String() {
+		t.Errorf("expected %s but got %s", circuit, decoded)
+	}
+	if decoded[9].(*UnitaryGate).Matrix != circuit[9].(*UnitaryGate).Matrix {
+		t.Error("unitary was not preserved exactly")
+	}
+	if _, ok := decoded[10].(Circuit); !ok {
+		t.Errorf("unexpected nested gate type: %T", decoded[10])
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj["version"] != float64(CircuitJSONVersion) {
+		t.Errorf("unexpected version: %v", obj["version"])
+	}
+}
+
+func TestMarshalGateErrors(t *testing.T) {
+	if _, err := MarshalGate(&FnGate{Str: "Fn"}); err == nil {
+		t.Error("expected error for FnGate")
+	}
+	if _, err := UnmarshalGate([]byte(`{"kind":"Foo","gate":{}}`)); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+	if _, _, err := UnmarshalCircuit([]byte(`{"version":2,"num_bits":1,"gates":[]}`)); err == nil {
+		t.Error("expected error for unknown version")
+	}
+}
@@ -91,3 +91,19 @@ func (m *Matrix2) Sqrt() {
 	m.M21 = m.M21 / t
 	m.M22 = (m.M22 + s) / t
 }
+
+// PhaseDistance computes the operator norm distance
+// between two unitaries, minimized over global phase.
+func (m *Matrix2) PhaseDistance(other *Matrix2) float64 {
+	// For the eigenvalue phases a and b of other^H*m, the
+	// distance is 2*sin(|a-b|/4). The eigenvalue gap is
+	// computed from the off-diagonal and the difference of
+	// the diagonal terms to avoid cancellation.
+	product := *other
+	product.ConjTranspose()
+	product.Mul(m)
+	halfDiff := (product.M11 - product.M22) / 2
+	gap := 2 * cmplx.Sqrt(halfDiff*halfDiff+product.M12*product.M21)
+	halfAngle := math.Asin(math.Min(1, cmplx.Abs(gap)/2))
+	return 2 * math.Sin(halfAngle/2)
+}
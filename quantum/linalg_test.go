@@ -1,6 +1,7 @@
 package quantum
 
 import (
+	"math"
 	"math/cmplx"
 	"testing"
 )
@@ -31,3 +32,41 @@ func TestMatrix2Sqrt(t *testing.T) {
 		t.Error("incorrect square")
 	}
 }
+
+func TestMatrix2PhaseDistance(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		m1 := RandomMatrix2()
+		m2 := RandomMatrix2()
+
+		// Minimize the norm of the difference by brute force
+		// over the global phase.
+		expected := math.Inf(1)
+		for j := 0; j < 10000; j++ {
+			phase := cmplx.Exp(complex(0, 2*math.Pi*float64(j)/10000))
+			diff := Matrix2{m1.M11 - phase*m2.M11, m1.M12 - phase*m2.M12,
+				m1.M21 - phase*m2.M21, m1.M22 - phase*m2.M22}
+			expected = math.Min(expected, matrix2Norm(&diff))
+		}
+		actual := m1.PhaseDistance(&m2)
+		if math.Abs(actual-expected) > 1e-3 {
+			t.Errorf("expected %f but got %f", expected, actual)
+		}
+
+		scaled := Matrix2{1i * m1.M11, 1i * m1.M12, 1i * m1.M21, 1i * m1.M22}
+		if d := m1.PhaseDistance(&scaled); d > 1e-7 {
+			t.Errorf("unexpected distance under global phase: %f", d)
+		}
+	}
+}
+
+// matrix2Norm computes the operator norm of a matrix.
+func matrix2Norm(m *Matrix2) float64 {
+	// The squared singular values are the eigenvalues of
+	// m^H*m, which is Hermitian.
+	h := *m
+	h.ConjTranspose()
+	h.Mul(m)
+	trace := real(h.Trace())
+	det := real(h.Det())
+	return math.Sqrt(trace/2 + math.Sqrt(math.Max(0, trace*trace/4-det)))
+}
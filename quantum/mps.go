@@ -0,0 +1,489 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"sort"
+)
+
+// mpsZero is the relative threshold below which singular
+// values are treated as zero, even if no truncation is
+// requested.
+const mpsZero = 1e-12
+
+// An MPSSimulation is a classical simulation of a quantum
+// computer that stores the state as a matrix product
+// state. Qubit i is stored in the i-th tensor of a chain.
+//
+// The memory required depends on the amount of
+// entanglement in the state rather than the number of
+// qubits, so low-entanglement circuits can be simulated
+// on very large registers.
+type MPSSimulation struct {
+	numBits int
+
+	// MaxBond is the maximum bond dimension to keep after
+	// a two-qubit gate. If it is 0, bonds are unlimited.
+	MaxBond int
+
+	// Cutoff is the fraction of the squared norm below
+	// which singular values are discarded after a
+	// two-qubit gate.
+	Cutoff float64
+
+	// TruncationError is the total squared norm that has
+	// been discarded due to MaxBond and Cutoff.
+	TruncationError float64
+
+	tensors []*mpsTensor
+
+	// center is the orthogonality center of the chain.
+	// All tensors to the left of it are left-isometries,
+	// and all tensors to the right are right-isometries.
+	center int
+}
+
+// An mpsTensor is a rank-3 tensor indexed by the left
+// bond, the physical qubit value, and the right bond.
+type mpsTensor struct {
+	left  int
+	right int
+	data  []complex128
+}
+
+func (m *mpsTensor) index(l, s, r int) int {
+	return (l*2+s)*m.right + r
+}
+
+// Create a new MPSSimulation with all qubits set to 0.
+func NewMPSSimulation(numBits int) *MPSSimulation {
+	return NewMPSSimulationBits(numBits, 0)
+}
+
+// Create a new MPSSimulation with a given bit-string.
+func NewMPSSimulationBits(numBits int, value uint) *MPSSimulation {
+	m := &MPSSimulation{numBits: numBits}
+	for i := 0; i < numBits; i++ {
+		t := &mpsTensor{left: 1, right: 1, data: make([]complex128, 2)}
+		if i < 64 && value&(1<<uint(i)) != 0 {
+			t.data[1] = 1
+		} else {
+			t.data[0] = 1
+		}
+		m.tensors = append(m.tensors, t)
+	}
+	return m
+}
+
+func (m *MPSSimulation) NumBits() int {
+	return m.numBits
+}
+
+func (m *MPSSimulation) InUse(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= m.numBits {
+		panic("bit index out of range")
+	}
+	return false
+}
+
+func (m *MPSSimulation) Measure(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= m.numBits {
+		panic("bit index out of range")
+	}
+	m.moveCenter(bitIdx)
+	t := m.tensors[bitIdx]
+	var zeroProb, oneProb float64
+	for l := 0; l < t.left; l++ {
+		for r := 0; r < t.right; r++ {
+			zeroProb += math.Pow(cmplx.Abs(t.data[t.index(l, 0, r)]), 2)
+			oneProb += math.Pow(cmplx.Abs(t.data[t.index(l, 1, r)]), 2)
+		}
+	}
+	isOne := rand.Float64()*(zeroProb+oneProb) > zeroProb
+	var scale complex128
+	if isOne {
+		scale = complex(1/math.Sqrt(oneProb), 0)
+	} else {
+		scale = complex(1/math.Sqrt(zeroProb), 0)
+	}
+	for l := 0; l < t.left; l++ {
+		for r := 0; r < t.right; r++ {
+			i0, i1 := t.index(l, 0, r), t.index(l, 1, r)
+			if isOne {
+				t.data[i0] = 0
+				t.data[i1] *= scale
+			} else {
+				t.data[i0] *= scale
+				t.data[i1] = 0
+			}
+		}
+	}
+	return isOne
+}
+
+func (m *MPSSimulation) Unitary(target int, mat *Matrix2) {
+	if target < 0 || target >= m.numBits {
+		panic("bit index out of range")
+	}
+	t := m.tensors[target]
+	for l := 0; l < t.left; l++ {
+		for r := 0; r < t.right; r++ {
+			i0, i1 := t.index(l, 0, r), t.index(l, 1, r)
+			p0, p1 := t.data[i0], t.data[i1]
+			t.data[i0] = mat.M11*p0 + mat.M12*p1
+			t.data[i1] = mat.M21*p0 + mat.M22*p1
+		}
+	}
+}
+
+func (m *MPSSimulation) CNot(control, target int) {
+	if control < 0 || control >= m.numBits || target < 0 || target >= m.numBits {
+		panic("bit index out of range")
+	}
+	if control == target {
+		panic("overlapping control and target is not invertible")
+	}
+
+	// Move the rightmost qubit next to the leftmost one,
+	// apply the gate, and then move it back.
+	a, b := control, target
+	if a > b {
+		a, b = b, a
+	}
+	swap := twoQubitSwap()
+	for i := b - 1; i > a; i-- {
+		m.applyTwoQubit(i, &swap)
+	}
+	cnot := twoQubitCNot(control < target)
+	m.applyTwoQubit(a, &cnot)
+	for i := a + 1; i < b; i++ {
+		m.applyTwoQubit(i, &swap)
+	}
+}
+
+// Amplitude computes the amplitude of a classical basis
+// state, given as one bool per qubit.
+func (m *MPSSimulation) Amplitude(value []bool) complex128 {
+	vec := []complex128{1}
+	for i, t := range m.tensors {
+		s := 0
+		if value[i] {
+			s = 1
+		}
+		next := make([]complex128, t.right)
+		for l, x := range vec {
+			for r := range next {
+				next[r] += x * t.data[t.index(l, s, r)]
+			}
+		}
+		vec = next
+	}
+	return vec[0]
+}
+
+// BondDims gets the dimension of each bond in the chain.
+func (m *MPSSimulation) BondDims() []int {
+	var res []int
+	for _, t := range m.tensors[:len(m.tensors)-1] {
+		res = append(res, t.right)
+	}
+	return res
+}
+
+// Simulation converts the state into a dense
+// Simulation. This is only feasible for small numbers of
+// qubits.
+func (m *MPSSimulation) Simulation() *Simulation {
+	s := NewSimulation(m.numBits)
+	value := make([]bool, m.numBits)
+	for i := range s.Phases {
+		for j := range value {
+			value[j] = i&(1<<uint(j)) != 0
+		}
+		s.Phases[i] = m.Amplitude(value)
+	}
+	return s
+}
+
+// moveCenter shifts the orthogonality center to the
+// given site.
+func (m *MPSSimulation) moveCenter(site int) {
+	for m.center < site {
+		t := m.tensors[m.center]
+		u, s, vh, k := svd(t.data, t.left*2, t.right)
+		k = numNonZero(s, k)
+		next := m.tensors[m.center+1]
+		newNext := &mpsTensor{left: k, right: next.right, data: make([]complex128, k*2*next.right)}
+		for i := 0; i < k; i++ {
+			for j := 0; j < t.right; j++ {
+				coeff := complex(s[i], 0) * vh[i*t.right+j]
+				for x := 0; x < 2*next.right; x++ {
+					newNext.data[i*2*next.right+x] += coeff * next.data[j*2*next.right+x]
+				}
+			}
+		}
+		m.tensors[m.center] = &mpsTensor{left: t.left, right: k, data: truncColumns(u, t.left*2, k)}
+		m.tensors[m.center+1] = newNext
+		m.center++
+	}
+	for m.center > site {
+		t := m.tensors[m.center]
+		u, s, vh, fullK := svd(t.data, t.left, 2*t.right)
+		k := numNonZero(s, fullK)
+		prev := m.tensors[m.center-1]
+		newPrev := &mpsTensor{left: prev.left, right: k, data: make([]complex128, prev.left*2*k)}
+		for x := 0; x < prev.left*2; x++ {
+			for j := 0; j < t.left; j++ {
+				p := prev.data[x*t.left+j]
+				for i := 0; i < k; i++ {
+					newPrev.data[x*k+i] += p * u[j*fullK+i] * complex(s[i], 0)
+				}
+			}
+		}
+		m.tensors[m.center] = &mpsTensor{left: k, right: t.right, data: vh[:k*2*t.right]}
+		m.tensors[m.center-1] = newPrev
+		m.center--
+	}
+}
+
+// applyTwoQubit applies a 4x4 gate to the sites i and
+// i+1, where the matrix g is indexed by (out, in) and
+// the left site is the more significant bit of each
+// index.
+func (m *MPSSimulation) applyTwoQubit(i int, g *[16]complex128) {
+	m.moveCenter(i)
+	a, b := m.tensors[i], m.tensors[i+1]
+
+	var theta [4][]complex128
+	for s := range theta {
+		s1, s2 := s>>1, s&1
+		theta[s] = make([]complex128, a.left*b.right)
+		for l := 0; l < a.left; l++ {
+			for k := 0; k < a.right; k++ {
+				x := a.data[a.index(l, s1, k)]
+				if x == 0 {
+					continue
+				}
+				for r := 0; r < b.right; r++ {
+					theta[s][l*b.right+r] += x * b.data[b.index(k, s2, r)]
+				}
+			}
+		}
+	}
+
+	rows, cols := a.left*2, 2*b.right
+	mat := make([]complex128, rows*cols)
+	for out := 0; out < 4; out++ {
+		t1, t2 := out>>1, out&1
+		for in := 0; in < 4; in++ {
+			coeff := g[out*4+in]
+			if coeff == 0 {
+				continue
+			}
+			for l := 0; l < a.left; l++ {
+				for r := 0; r < b.right; r++ {
+					mat[(l*2+t1)*cols+t2*b.right+r] += coeff * theta[in][l*b.right+r]
+				}
+			}
+		}
+	}
+
+	u, s, vh, k := svd(mat, rows, cols)
+	keep := m.truncate(s, k)
+
+	newA := &mpsTensor{left: a.left, right: keep, data: truncColumns(u, rows, keep)}
+	newB := &mpsTensor{left: keep, right: b.right, data: make([]complex128, keep*cols)}
+	for j := 0; j < keep; j++ {
+		for x := 0; x < cols; x++ {
+			newB.data[j*cols+x] = complex(s[j], 0) * vh[j*cols+x]
+		}
+	}
+	m.tensors[i], m.tensors[i+1] = newA, newB
+	m.center = i + 1
+}
+
+// truncate decides how many singular values to keep,
+// records the truncation error, and rescales the kept
+// singular values to preserve the norm.
+func (m *MPSSimulation) truncate(s []float64, k int) int {
+	var total float64
+	for _, x := range s[:k] {
+		total += x * x
+	}
+	keep := numNonZero(s, k)
+	if m.MaxBond > 0 && keep > m.MaxBond {
+		keep = m.MaxBond
+	}
+	var discarded float64
+	for _, x := range s[keep:k] {
+		discarded += x * x
+	}
+	for keep > 1 && discarded+s[keep-1]*s[keep-1] <= m.Cutoff*total {
+		keep--
+		discarded += s[keep] * s[keep]
+	}
+	if discarded > 0 {
+		m.TruncationError += discarded / total
+		scale := math.Sqrt(total / (total - discarded))
+		for i := range s[:keep] {
+			s[i] *= scale
+		}
+	}
+	return keep
+}
+
+func twoQubitSwap() [16]complex128 {
+	var res [16]complex128
+	for in := 0; in < 4; in++ {
+		out := (in >> 1) | ((in & 1) << 1)
+		res[out*4+in] = 1
+	}
+	return res
+}
+
+func twoQubitCNot(controlLeft bool) [16]complex128 {
+	var res [16]complex128
+	for in := 0; in < 4; in++ {
+		out := in
+		if controlLeft && in&2 != 0 {
+			out ^= 1
+		} else if !controlLeft && in&1 != 0 {
+			out ^= 2
+		}
+		res[out*4+in] = 1
+	}
+	return res
+}
+
+func numNonZero(s []float64, k int) int {
+	n := 1
+	for n < k && s[n] > mpsZero*s[0] {
+		n++
+	}
+	return n
+}
+
+func truncColumns(m []complex128, rows, k int) []complex128 {
+	cols := len(m) / rows
+	if cols == k {
+		return m
+	}
+	res := make([]complex128, rows*k)
+	for i := 0; i < rows; i++ {
+		copy(res[i*k:(i+1)*k], m[i*cols:i*cols+k])
+	}
+	return res
+}
+
+// svd computes the singular value decomposition of a
+// row-major matrix, such that m = u*diag(s)*vh.
+//
+// The result has k = min(rows, cols) singular values in
+// descending order, where u is rows x k and vh is
+// k x cols.
+func svd(m []complex128, rows, cols int) (u []complex128, s []float64, vh []complex128, k int) {
+	if cols > rows {
+		// Decompose the conjugate transpose instead.
+		mH := make([]complex128, len(m))
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				mH[j*rows+i] = cmplx.Conj(m[i*cols+j])
+			}
+		}
+		v, s, uH, k := svd(mH, cols, rows)
+		return conjTranspose(uH, k, rows), s, conjTranspose(v, cols, k), k
+	}
+
+	// One-sided Jacobi: orthogonalize the columns of a by
+	// applying rotations, accumulating them in v.
+	a := append([]complex128{}, m...)
+	v := make([]complex128, cols*cols)
+	for i := 0; i < cols; i++ {
+		v[i*cols+i] = 1
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+		rotated := false
+		for p := 0; p < cols; p++ {
+			for q := p + 1; q < cols; q++ {
+				var alpha, beta float64
+				var gamma complex128
+				for i := 0; i < rows; i++ {
+					ap, aq := a[i*cols+p], a[i*cols+q]
+					alpha += real(ap)*real(ap) + imag(ap)*imag(ap)
+					beta += real(aq)*real(aq) + imag(aq)*imag(aq)
+					gamma += cmplx.Conj(ap) * aq
+				}
+				absGamma := cmplx.Abs(gamma)
+				if absGamma == 0 || absGamma <= 1e-15*math.Sqrt(alpha*beta) {
+					continue
+				}
+				rotated = true
+				phase := cmplx.Conj(gamma) / complex(absGamma, 0)
+				zeta := (beta - alpha) / (2 * absGamma)
+				t := 1 / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				if zeta < 0 {
+					t = -t
+				}
+				c := complex(1/math.Sqrt(1+t*t), 0)
+				sn := c * complex(t, 0)
+				jacobiRotate(a, rows, cols, p, q, c, sn, phase)
+				jacobiRotate(v, cols, cols, p, q, c, sn, phase)
+			}
+		}
+		if !rotated {
+			break
+		}
+	}
+
+	k = cols
+	norms := make([]float64, cols)
+	for j := 0; j < cols; j++ {
+		for i := 0; i < rows; i++ {
+			norms[j] += math.Pow(cmplx.Abs(a[i*cols+j]), 2)
+		}
+		norms[j] = math.Sqrt(norms[j])
+	}
+	order := make([]int, cols)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return norms[order[i]] > norms[order[j]]
+	})
+
+	u = make([]complex128, rows*k)
+	s = make([]float64, k)
+	vh = make([]complex128, k*cols)
+	for newIdx, oldIdx := range order {
+		s[newIdx] = norms[oldIdx]
+		for i := 0; i < rows; i++ {
+			if norms[oldIdx] > 0 {
+				u[i*k+newIdx] = a[i*cols+oldIdx] / complex(norms[oldIdx], 0)
+			}
+		}
+		for j := 0; j < cols; j++ {
+			vh[newIdx*cols+j] = cmplx.Conj(v[j*cols+oldIdx])
+		}
+	}
+	return
+}
+
+func jacobiRotate(m []complex128, rows, cols, p, q int, c, s, phase complex128) {
+	for i := 0; i < rows; i++ {
+		x := m[i*cols+p]
+		y := m[i*cols+q] * phase
+		m[i*cols+p] = c*x - s*y
+		m[i*cols+q] = s*x + c*y
+	}
+}
+
+func conjTranspose(m []complex128, rows, cols int) []complex128 {
+	res := make([]complex128, len(m))
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			res[j*rows+i] = cmplx.Conj(m[i*cols+j])
+		}
+	}
+	return res
+}
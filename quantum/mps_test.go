@@ -0,0 +1,130 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+func TestMPSSimulationGates(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		m := NewMPSSimulation(6)
+		s := NewSimulation(6)
+		for j := 0; j < 30; j++ {
+			bits := rand.Perm(6)
+			var g Gate
+			switch rand.Intn(5) {
+			case 0:
+				g = &HGate{Bit: bits[0]}
+			case 1:
+				g = &TGate{Bit: bits[0]}
+			case 2:
+				g = &CNotGate{Control: bits[0], Target: bits[1]}
+			case 3:
+				g = &CSqrtNotGate{Control: bits[0], Target: bits[1]}
+			case 4:
+				g = &CCNotGate{Control1: bits[0], Control2: bits[1], Target: bits[2]}
+			}
+			g.Apply(m)
+			g.Apply(s)
+		}
+		if !m.Simulation().ApproxEqual(s, 1e-8) {
+			t.Fatal("incorrect state")
+		}
+		if m.TruncationError > 1e-8 {
+			t.Error("unexpected truncation error", m.TruncationError)
+		}
+	}
+}
+
+func TestMPSSimulationAdd(t *testing.T) {
+	const numBits = 30
+	source := make(Reg, numBits)
+	target := make(Reg, numBits)
+	for i := range source {
+		source[i] = i
+		target[i] = i + numBits
+	}
+	carry := numBits * 2
+	for i := 0; i < 3; i++ {
+		x := uint(rand.Int63n(1 << numBits))
+		y := uint(rand.Int63n(1 << numBits))
+		m := NewMPSSimulationBits(numBits*2+1, source.Inject(target.Inject(0, y), x))
+		Add(m, source, target, &carry)
+
+		sum := x + y
+		expected := make([]bool, numBits*2+1)
+		for j := 0; j < numBits; j++ {
+			expected[j] = x&(1<<uint(j)) != 0
+			expected[j+numBits] = sum&(1<<uint(j)) != 0
+		}
+		expected[carry] = sum&(1<<numBits) != 0
+		if cmplx.Abs(m.Amplitude(expected)-1) > 1e-8 {
+			t.Errorf("incorrect sum for %d+%d", x, y)
+		}
+		for _, dim := range m.BondDims() {
+			if dim != 1 {
+				t.Fatal("unexpected entanglement in bond dimensions", m.BondDims())
+			}
+		}
+	}
+}
+
+func TestMPSSimulationTruncation(t *testing.T) {
+	m := NewMPSSimulation(4)
+	m.MaxBond = 1
+	H(m, 0)
+	m.CNot(0, 3)
+	if math.Abs(m.TruncationError-0.5) > 1e-8 {
+		t.Error("unexpected truncation error", m.TruncationError)
+	}
+	s := m.Simulation()
+	var norm float64
+	for _, ph := range s.Phases {
+		norm += math.Pow(cmplx.Abs(ph), 2)
+	}
+	if math.Abs(norm-1) > 1e-8 {
+		t.Error("state should remain normalized, got norm", norm)
+	}
+}
+
+func TestMPSSimulationMeasure(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		m := NewMPSSimulation(5)
+		H(m, 1)
+		m.CNot(1, 4)
+		m.CNot(4, 2)
+		res := m.Measure(4)
+		if m.Measure(1) != res || m.Measure(2) != res || m.Measure(0) || m.Measure(3) {
+			t.Fatal("unexpected measurement results")
+		}
+	}
+}
+
+func TestSVD(t *testing.T) {
+	for _, shape := range [][2]int{{4, 4}, {6, 3}, {3, 7}} {
+		rows, cols := shape[0], shape[1]
+		m := make([]complex128, rows*cols)
+		for i := range m {
+			m[i] = complex(rand.NormFloat64(), rand.NormFloat64())
+		}
+		u, s, vh, k := svd(m, rows, cols)
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				var sum complex128
+				for l := 0; l < k; l++ {
+					sum += u[i*k+l] * complex(s[l], 0) * vh[l*cols+j]
+				}
+				if cmplx.Abs(sum-m[i*cols+j]) > 1e-8 {
+					t.Fatalf("incorrect reconstruction for shape %v", shape)
+				}
+			}
+		}
+		for l := 1; l < k; l++ {
+			if s[l] > s[l-1] {
+				t.Fatal("singular values are not sorted")
+			}
+		}
+	}
+}
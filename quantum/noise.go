@@ -0,0 +1,243 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+)
+
+// A Channel is a single-qubit quantum channel, described
+// by a set of Kraus operators.
+type Channel []Matrix2
+
+// DepolarizingChannel creates a channel which applies a
+// uniformly random Pauli error with probability p.
+func DepolarizingChannel(p float64) Channel {
+	id := complex(math.Sqrt(1-p), 0)
+	s := complex(math.Sqrt(p/3), 0)
+	return Channel{
+		{id, 0, 0, id},
+		{0, s, s, 0},
+		{0, complex(0, -1) * s, complex(0, 1) * s, 0},
+		{s, 0, 0, -s},
+	}
+}
+
+// BitFlipChannel creates a channel which applies an X
+// gate with probability p.
+func BitFlipChannel(p float64) Channel {
+	id := complex(math.Sqrt(1-p), 0)
+	s := complex(math.Sqrt(p), 0)
+	return Channel{{id, 0, 0, id}, {0, s, s, 0}}
+}
+
+// PhaseFlipChannel creates a channel which applies a Z
+// gate with probability p.
+func PhaseFlipChannel(p float64) Channel {
+	id := complex(math.Sqrt(1-p), 0)
+	s := complex(math.Sqrt(p), 0)
+	return Channel{{id, 0, 0, id}, {s, 0, 0, -s}}
+}
+
+// AmplitudeDampingChannel creates a channel which decays
+// |1> to |0> with probability gamma.
+func AmplitudeDampingChannel(gamma float64) Channel {
+	return Channel{
+		{1, 0, 0, complex(math.Sqrt(1-gamma), 0)},
+		{0, complex(math.Sqrt(gamma), 0), 0, 0},
+	}
+}
+
+// PhaseDampingChannel creates a channel which loses phase
+// information without any energy loss.
+// The parameter lambda is the probability that the qubit
+// scatters, destroying its coherence.
+func PhaseDampingChannel(lambda float64) Channel {
+	return Channel{
+		{1, 0, 0, complex(math.Sqrt(1-lambda), 0)},
+		{0, 0, 0, complex(math.Sqrt(lambda), 0)},
+	}
+}
+
+// Valid checks that the Kraus operators satisfy the
+// completeness relation sum_k K_k^H K_k = I.
+func (c Channel) Valid(tol float64) bool {
+	var sum Matrix2
+	for _, k := range c {
+		kH := k
+		kH.ConjTranspose()
+		kH.Mul(&k)
+		sum.M11 += kH.M11
+		sum.M12 += kH.M12
+		sum.M21 += kH.M21
+		sum.M22 += kH.M22
+	}
+	return cmplx.Abs(sum.M11-1) <= tol && cmplx.Abs(sum.M12) <= tol &&
+		cmplx.Abs(sum.M21) <= tol && cmplx.Abs(sum.M22-1) <= tol
+}
+
+// mixedUnitary decomposes the channel into a probability
+// distribution over unitaries, if possible.
+func (c Channel) mixedUnitary() ([]float64, []Matrix2, bool) {
+	var probs []float64
+	var unitaries []Matrix2
+	for _, k := range c {
+		kH := k
+		kH.ConjTranspose()
+		kH.Mul(&k)
+		p := real(kH.M11)
+		if cmplx.Abs(kH.M12) > epsilon || cmplx.Abs(kH.M21) > epsilon ||
+			cmplx.Abs(kH.M22-kH.M11) > epsilon {
+			return nil, nil, false
+		}
+		if p < epsilon {
+			continue
+		}
+		scale := complex(1/math.Sqrt(p), 0)
+		probs = append(probs, p)
+		unitaries = append(unitaries, Matrix2{k.M11 * scale, k.M12 * scale, k.M21 * scale,
+			k.M22 * scale})
+	}
+	return probs, unitaries, true
+}
+
+// A KrausComputer is a Computer that can directly apply a
+// quantum channel to one of its qubits.
+type KrausComputer interface {
+	Computer
+	ApplyChannel(target int, ch Channel)
+}
+
+// ApplyChannel applies the channel exactly by updating
+// the density matrix.
+func (d *DensitySimulation) ApplyChannel(target int, ch Channel) {
+	res := make([]complex128, len(d.Rho))
+	for _, k := range ch {
+		d1 := d.Copy()
+		d1.Unitary(target, &k)
+		for i, x := range d1.Rho {
+			res[i] += x
+		}
+	}
+	d.Rho = res
+}
+
+// ApplyChannel samples a single trajectory of the channel
+// by randomly choosing a Kraus operator according to its
+// probability and then renormalizing the state.
+func (s *Simulation) ApplyChannel(target int, ch Channel) {
+	x := rand.Float64()
+	var last *Simulation
+	var lastProb float64
+	for _, k := range ch {
+		s1 := s.Copy()
+		s1.Unitary(target, &k)
+		var prob float64
+		for _, ph := range s1.Phases {
+			prob += math.Pow(cmplx.Abs(ph), 2)
+		}
+		if prob < epsilon {
+			continue
+		}
+		last, lastProb = s1, prob
+		x -= prob
+		if x < 0 {
+			break
+		}
+	}
+	scale := complex(1/math.Sqrt(lastProb), 0)
+	for i, ph := range last.Phases {
+		s.Phases[i] = ph * scale
+	}
+}
+
+// A NoiseModel decides which channels to apply after
+// each primitive gate.
+//
+// Per-qubit entries take precedence over the default
+// channels. A nil Channel means that no noise is applied.
+type NoiseModel struct {
+	// Unitary is applied to the target of every Unitary.
+	Unitary Channel
+
+	// CNot is applied to both qubits of every CNot.
+	CNot Channel
+
+	QubitUnitary map[int]Channel
+	QubitCNot    map[int]Channel
+}
+
+// UnitaryChannel gets the channel to apply to a qubit
+// after it is the target of a Unitary.
+func (n *NoiseModel) UnitaryChannel(bit int) Channel {
+	if ch, ok := n.QubitUnitary[bit]; ok {
+		return ch
+	}
+	return n.Unitary
+}
+
+// CNotChannel gets the channel to apply to a qubit after
+// it is involved in a CNot.
+func (n *NoiseModel) CNotChannel(bit int) Channel {
+	if ch, ok := n.QubitCNot[bit]; ok {
+		return ch
+	}
+	return n.CNot
+}
+
+// A NoisyComputer is a Computer that injects noise after
+// every gate it applies to an underlying Computer.
+//
+// If the underlying Computer is a KrausComputer, channels
+// are applied directly. Otherwise, only channels which
+// are mixtures of unitaries are supported, and they are
+// sampled stochastically.
+type NoisyComputer struct {
+	Computer Computer
+	Model    *NoiseModel
+}
+
+func (n *NoisyComputer) NumBits() int {
+	return n.Computer.NumBits()
+}
+
+func (n *NoisyComputer) InUse(bit int) bool {
+	return n.Computer.InUse(bit)
+}
+
+func (n *NoisyComputer) Measure(bitIdx int) bool {
+	return n.Computer.Measure(bitIdx)
+}
+
+func (n *NoisyComputer) Unitary(target int, m *Matrix2) {
+	n.Computer.Unitary(target, m)
+	n.applyChannel(target, n.Model.UnitaryChannel(target))
+}
+
+func (n *NoisyComputer) CNot(control, target int) {
+	n.Computer.CNot(control, target)
+	n.applyChannel(control, n.Model.CNotChannel(control))
+	n.applyChannel(target, n.Model.CNotChannel(target))
+}
+
+func (n *NoisyComputer) applyChannel(target int, ch Channel) {
+	if len(ch) == 0 {
+		return
+	}
+	if kc, ok := n.Computer.(KrausComputer); ok {
+		kc.ApplyChannel(target, ch)
+		return
+	}
+	probs, unitaries, ok := ch.mixedUnitary()
+	if !ok {
+		panic("computer does not support non-unitary channels")
+	}
+	x := rand.Float64()
+	for i, p := range probs {
+		x -= p
+		if x < 0 || i == len(probs)-1 {
+			n.Computer.Unitary(target, &unitaries[i])
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestChannelValid(t *testing.T) {
+	channels := map[string]Channel{
+		"Depolarizing":     DepolarizingChannel(0.1),
+		"BitFlip":          BitFlipChannel(0.2),
+		"PhaseFlip":        PhaseFlipChannel(0.3),
+		"AmplitudeDamping": AmplitudeDampingChannel(0.4),
+		"PhaseDamping":     PhaseDampingChannel(0.5),
+	}
+	for name, ch := range channels {
+		if !ch.Valid(1e-8) {
+			t.Errorf("%s channel is invalid", name)
+		}
+	}
+	if (Channel{{1, 0, 0, 0.5}}).Valid(1e-8) {
+		t.Error("incomplete channel should be invalid")
+	}
+}
+
+func TestNoisyComputerDensity(t *testing.T) {
+	d := NewDensitySimulation(2)
+	n := &NoisyComputer{
+		Computer: d,
+		Model: &NoiseModel{
+			Unitary:      BitFlipChannel(0.25),
+			QubitUnitary: map[int]Channel{1: nil},
+		},
+	}
+	X(n, 0)
+	X(n, 1)
+	probs := d.Probabilities()
+	if math.Abs(probs[3]-0.75) > 1e-8 || math.Abs(probs[2]-0.25) > 1e-8 {
+		t.Error("unexpected probabilities", probs)
+	}
+
+	d = NewDensitySimulation(1)
+	n = &NoisyComputer{Computer: d, Model: &NoiseModel{Unitary: DepolarizingChannel(0.2)}}
+	for i := 0; i < 100; i++ {
+		H(n, 0)
+	}
+	if math.Abs(d.Purity()-0.5) > 1e-3 {
+		t.Error("expected maximally mixed state but got purity", d.Purity())
+	}
+}
+
+func TestNoisyComputerTrajectories(t *testing.T) {
+	model := &NoiseModel{
+		Unitary: AmplitudeDampingChannel(0.1),
+		CNot:    PhaseDampingChannel(0.2),
+	}
+	circuit := func(c Computer) {
+		H(c, 0)
+		c.CNot(0, 1)
+		T(c, 1)
+		H(c, 1)
+		CCNot(c, 0, 1, 2)
+	}
+
+	d := NewDensitySimulation(3)
+	circuit(&NoisyComputer{Computer: d, Model: model})
+	expected := d.Probabilities()
+
+	const numTrajectories = 4000
+	actual := make([]float64, len(expected))
+	for i := 0; i < numTrajectories; i++ {
+		s := NewSimulation(3)
+		circuit(&NoisyComputer{Computer: s, Model: model})
+		for j, ph := range s.Phases {
+			actual[j] += math.Pow(cmplx.Abs(ph), 2) / numTrajectories
+		}
+	}
+	for i, x := range expected {
+		if math.Abs(x-actual[i]) > 0.03 {
+			t.Errorf("state %d: expected probability %f but got %f", i, x, actual[i])
+		}
+	}
+}
+
+func TestNoisyComputerMixedUnitary(t *testing.T) {
+	var numFlips int
+	for i := 0; i < 1000; i++ {
+		c := &MappedComputer{C: NewSimulation(1), Mapping: []int{0}}
+		n := &NoisyComputer{Computer: c, Model: &NoiseModel{Unitary: BitFlipChannel(0.3)}}
+		Z(n, 0)
+		if c.Measure(0) {
+			numFlips++
+		}
+	}
+	if numFlips < 230 || numFlips > 370 {
+		t.Error("unexpected number of flips:", numFlips)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for non-unitary channel")
+		}
+	}()
+	c := &MappedComputer{C: NewSimulation(1), Mapping: []int{0}}
+	n := &NoisyComputer{Computer: c, Model: &NoiseModel{Unitary: AmplitudeDampingChannel(0.3)}}
+	X(n, 0)
+}
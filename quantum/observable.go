@@ -0,0 +1,102 @@
+package quantum
+
+import (
+	"fmt"
+	"math/cmplx"
+)
+
+// An Observable is a Hermitian operator whose expectation
+// can be measured on a Simulation.
+type Observable interface {
+	// Mul computes the product of the operator and a
+	// state vector, in the layout of Simulation.Phases.
+	Mul(phases []complex128) []complex128
+}
+
+// Expectation computes the expected value of an
+// observable for the state of a Simulation.
+func Expectation(o Observable, s *Simulation) float64 {
+	return innerProductReal(s.Phases, o.Mul(s.Phases))
+}
+
+// CircuitExpectation computes the expected value of an
+// observable after applying a circuit, with the given
+// parameter values, to the all-zero state.
+func CircuitExpectation(numBits int, c Circuit, o Observable, values map[string]float64) float64 {
+	s := NewSimulation(numBits)
+	c.Bind(values).Apply(s)
+	return Expectation(o, s)
+}
+
+// A PauliTerm is a scaled tensor product of Pauli
+// operators.
+//
+// Paulis[i] is the operator for qubit i, and is one of
+// 'I', 'X', 'Y', or 'Z'. Qubits past the end of Paulis
+// use the identity.
+type PauliTerm struct {
+	Coeff  float64
+	Paulis string
+}
+
+// A PauliSum is an Observable made up of a sum of Pauli
+// terms, such as a Hamiltonian.
+type PauliSum []PauliTerm
+
+func (p PauliSum) Mul(phases []complex128) []complex128 {
+	res := make([]complex128, len(phases))
+	for _, term := range p {
+		var flipMask int
+		for bit, op := range term.Paulis {
+			switch op {
+			case 'I', 'Z':
+			case 'X', 'Y':
+				flipMask |= 1 << uint(bit)
+			default:
+				panic(fmt.Sprintf("invalid Pauli operator: %c", op))
+			}
+		}
+		for i, amp := range phases {
+			if amp == 0 {
+				continue
+			}
+			factor := complex(term.Coeff, 0)
+			for bit, op := range term.Paulis {
+				isOne := i&(1<<uint(bit)) != 0
+				switch op {
+				case 'Y':
+					if isOne {
+						factor *= -1i
+					} else {
+						factor *= 1i
+					}
+				case 'Z':
+					if isOne {
+						factor = -factor
+					}
+				}
+			}
+			res[i^flipMask] += factor * amp
+		}
+	}
+	return res
+}
+
+func (p PauliSum) String() string {
+	var res string
+	for i, term := range p {
+		if i > 0 {
+			res += " + "
+		}
+		res += formatFloat(term.Coeff) + "*" + term.Paulis
+	}
+	return res
+}
+
+func innerProductReal(v1, v2 []complex128) float64 {
+	var res float64
+	for i, x := range v1 {
+		res += real(cmplx.Conj(x) * v2[i])
+	}
+	return res
+}
@@ -0,0 +1,46 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestPauliSumMul(t *testing.T) {
+	s := RandomSimulation(3)
+	p := PauliSum{{Coeff: 0.5, Paulis: "XYZ"}, {Coeff: -2, Paulis: "IZ"}}
+	actual := p.Mul(s.Phases)
+
+	term1 := s.Copy()
+	applyPauliString(term1, "+XYZ")
+	term2 := s.Copy()
+	applyPauliString(term2, "+IZ")
+	for i, x := range actual {
+		expected := 0.5*term1.Phases[i] - 2*term2.Phases[i]
+		if cmplx.Abs(x-expected) > 1e-8 {
+			t.Fatalf("index %d: expected %f but got %f", i, expected, x)
+		}
+	}
+}
+
+func TestExpectation(t *testing.T) {
+	s := NewSimulation(2)
+	H(s, 0)
+	s.CNot(0, 1)
+	testCases := []struct {
+		Observable Observable
+		Expected   float64
+	}{
+		{PauliSum{{Coeff: 1, Paulis: "ZI"}}, 0},
+		{PauliSum{{Coeff: 1, Paulis: "ZZ"}}, 1},
+		{PauliSum{{Coeff: 1, Paulis: "XX"}}, 1},
+		{PauliSum{{Coeff: 1, Paulis: "YY"}}, -1},
+		{PauliSum{{Coeff: 2, Paulis: "XX"}, {Coeff: 0.5, Paulis: "YY"}}, 1.5},
+	}
+	for i, tc := range testCases {
+		actual := Expectation(tc.Observable, s)
+		if math.Abs(actual-tc.Expected) > 1e-8 {
+			t.Errorf("case %d: expected %f but got %f", i, tc.Expected, actual)
+		}
+	}
+}
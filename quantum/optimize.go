@@ -0,0 +1,306 @@
+package quantum
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// OptimizeStats reports the effect of Optimize.
+type OptimizeStats struct {
+	// Before is the number of primitive gates in the
+	// original circuit.
+	Before int
+
+	// After is the number of gates in the optimized
+	// circuit.
+	After int
+}
+
+// Optimize produces a shorter circuit that is equivalent
+// to c on numBits qubits.
+//
+// The circuit is first expanded into primitive gates
+// (single-qubit unitaries and CNots). Gates are then
+// commuted past each other where legal so that inverse
+// pairs (e.g. H*H or adjacent identical CNots) cancel and
+// consecutive phase gates merge into a single T, S, Z,
+// S*, or T*.
+//
+// The circuit must not perform measurements, and every
+// gate must support any Computer.
+func Optimize(numBits int, c Circuit) (Circuit, *OptimizeStats) {
+	recorded := Record(numBits, c.Apply)
+	stats := &OptimizeStats{Before: len(recorded)}
+
+	gates := make([]Gate, len(recorded))
+	for i, g := range recorded {
+		gates[i] = toPhaseGate(g)
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := 0; i < len(gates); i++ {
+			for j := i + 1; j < len(gates); j++ {
+				if merged, ok := mergeGates(gates[i], gates[j]); ok {
+					// gates[i] commutes with everything up to j,
+					// so the merged gates take the place of j.
+					rest := append(append([]Gate{}, merged...), gates[j+1:]...)
+					gates = append(append(gates[:i], gates[i+1:j]...), rest...)
+					changed = true
+
+					// The gate before i may now cancel with
+					// the gate after it.
+					i -= 2
+					if i < -1 {
+						i = -1
+					}
+					break
+				}
+				if !gatesCommute(gates[i], gates[j]) {
+					break
+				}
+			}
+		}
+	}
+
+	var res Circuit
+	for _, g := range gates {
+		if p, ok := g.(*phaseGate); ok {
+			res = append(res, p.Primitives()...)
+		} else {
+			res = append(res, g)
+		}
+	}
+	stats.After = len(res)
+	return res, stats
+}
+
+// A phaseGate applies a phase of Eighths*pi/4 to the 1
+// state of a qubit.
+//
+// It is used while optimizing so that T, S, and Z gates
+// can be merged by addition.
+type phaseGate struct {
+	Bit     int
+	Eighths int
+}
+
+func (p *phaseGate) String() string {
+	return fmt.Sprintf("Phase(%d, %d*pi/4)", p.Bit, p.Eighths)
+}
+
+func (p *phaseGate) Apply(c Computer) {
+	c.Unitary(p.Bit, &Matrix2{1, 0, 0, cmplx.Exp(complex(0, float64(p.Eighths)*math.Pi/4))})
+}
+
+func (p *phaseGate) Inverse() Gate {
+	return &phaseGate{Bit: p.Bit, Eighths: (8 - p.Eighths) % 8}
+}
+
+// Primitives expresses the phase with as few T, S, and Z
+// gates as possible.
+func (p *phaseGate) Primitives() []Gate {
+	return phaseGates(p.Bit, p.Eighths)
+}
+
+// phaseGates creates the shortest sequence of T, S, and Z
+// gates that applies a phase of eighths*pi/4.
+func phaseGates(bit, eighths int) []Gate {
+	switch ((eighths % 8) + 8) % 8 {
+	case 1:
+		return []Gate{&TGate{Bit: bit}}
+	case 2:
+		return []Gate{&SGate{Bit: bit}}
+	case 3:
+		return []Gate{&SGate{Bit: bit}, &TGate{Bit: bit}}
+	case 4:
+		return []Gate{&ZGate{Bit: bit}}
+	case 5:
+		return []Gate{&ZGate{Bit: bit}, &TGate{Bit: bit}}
+	case 6:
+		return []Gate{&SGate{Bit: bit, Conjugate: true}}
+	case 7:
+		return []Gate{&TGate{Bit: bit, Conjugate: true}}
+	default:
+		return nil
+	}
+}
+
+// toPhaseGate converts T, S, and Z gates to phaseGates,
+// leaving other gates unchanged.
+func toPhaseGate(g Gate) Gate {
+	switch g := g.(type) {
+	case *TGate:
+		if g.Conjugate {
+			return &phaseGate{Bit: g.Bit, Eighths: 7}
+		}
+		return &phaseGate{Bit: g.Bit, Eighths: 1}
+	case *SGate:
+		if g.Conjugate {
+			return &phaseGate{Bit: g.Bit, Eighths: 6}
+		}
+		return &phaseGate{Bit: g.Bit, Eighths: 2}
+	case *ZGate:
+		return &phaseGate{Bit: g.Bit, Eighths: 4}
+	}
+	return g
+}
+
+// mergeGates attempts to replace g1 followed by g2 with a
+// shorter sequence of gates.
+func mergeGates(g1, g2 Gate) ([]Gate, bool) {
+	if c1, ok := g1.(*CNotGate); ok {
+		if c2, ok := g2.(*CNotGate); ok && *c1 == *c2 {
+			return nil, true
+		}
+		return nil, false
+	}
+	bit1, bit2 := singleGateBit(g1), singleGateBit(g2)
+	if bit1 == -1 || bit1 != bit2 {
+		return nil, false
+	}
+	switch g1 := g1.(type) {
+	case *phaseGate:
+		if g2, ok := g2.(*phaseGate); ok {
+			sum := (g1.Eighths + g2.Eighths) % 8
+			if sum == 0 {
+				return nil, true
+			}
+			return []Gate{&phaseGate{Bit: bit1, Eighths: sum}}, true
+		}
+	case *HGate:
+		if _, ok := g2.(*HGate); ok {
+			return nil, true
+		}
+	case *XGate:
+		if _, ok := g2.(*XGate); ok {
+			return nil, true
+		}
+	case *YGate:
+		if _, ok := g2.(*YGate); ok {
+			return nil, true
+		}
+	case *SqrtTGate:
+		if g2, ok := g2.(*SqrtTGate); ok {
+			if g1.Conjugate != g2.Conjugate {
+				return nil, true
+			} else if g1.Conjugate {
+				return []Gate{&phaseGate{Bit: bit1, Eighths: 7}}, true
+			}
+			return []Gate{&phaseGate{Bit: bit1, Eighths: 1}}, true
+		}
+	}
+
+	// Arbitrary unitaries absorb any neighboring gates.
+	_, ok1 := g1.(*UnitaryGate)
+	_, ok2 := g2.(*UnitaryGate)
+	if ok1 || ok2 {
+		m := singleGateMatrix(g2)
+		m1 := singleGateMatrix(g1)
+		m.Mul(&m1)
+		if cmplx.Abs(m.M11-1) < epsilon && cmplx.Abs(m.M12) < epsilon &&
+			cmplx.Abs(m.M21) < epsilon && cmplx.Abs(m.M22-1) < epsilon {
+			return nil, true
+		}
+		return []Gate{toPhaseGate(unitaryToGate(bit1, &m))}, true
+	}
+	return nil, false
+}
+
+// gatesCommute checks if two primitive gates commute.
+func gatesCommute(g1, g2 Gate) bool {
+	c1, isCNot1 := g1.(*CNotGate)
+	c2, isCNot2 := g2.(*CNotGate)
+	if isCNot1 && isCNot2 {
+		return c1.Control != c2.Target && c1.Target != c2.Control
+	} else if isCNot1 {
+		return singleCommutesCNot(g2, c1)
+	} else if isCNot2 {
+		return singleCommutesCNot(g1, c2)
+	}
+	if singleGateBit(g1) != singleGateBit(g2) {
+		return true
+	}
+	return isDiagonalGate(g1) && isDiagonalGate(g2)
+}
+
+func singleCommutesCNot(g Gate, c *CNotGate) bool {
+	bit := singleGateBit(g)
+	if bit == c.Control {
+		return isDiagonalGate(g)
+	} else if bit == c.Target {
+		_, ok := g.(*XGate)
+		return ok
+	}
+	return true
+}
+
+// singleGateBit gets the qubit of a single-qubit gate, or
+// returns -1 for other gates.
+func singleGateBit(g Gate) int {
+	switch g := g.(type) {
+	case *phaseGate:
+		return g.Bit
+	case *HGate:
+		return g.Bit
+	case *XGate:
+		return g.Bit
+	case *YGate:
+		return g.Bit
+	case *SqrtTGate:
+		return g.Bit
+	case *UnitaryGate:
+		return g.Bit
+	}
+	return -1
+}
+
+func isDiagonalGate(g Gate) bool {
+	switch g := g.(type) {
+	case *phaseGate, *SqrtTGate:
+		return true
+	case *UnitaryGate:
+		return g.Matrix.M12 == 0 && g.Matrix.M21 == 0
+	}
+	return false
+}
+
+// singleGateMatrix computes the matrix of a single-qubit
+// gate.
+func singleGateMatrix(g Gate) Matrix2 {
+	c := &matrixComputer{bit: singleGateBit(g), m: NewMatrix2()}
+	g.Apply(c)
+	return c.m
+}
+
+// matrixComputer is a Computer that accumulates the
+// product of the unitaries applied to one qubit.
+type matrixComputer struct {
+	bit int
+	m   Matrix2
+}
+
+func (m *matrixComputer) NumBits() int {
+	return m.bit + 1
+}
+
+func (m *matrixComputer) InUse(bit int) bool {
+	return false
+}
+
+func (m *matrixComputer) Measure(bitIdx int) bool {
+	panic("measurement is not unitary")
+}
+
+func (m *matrixComputer) Unitary(target int, mat *Matrix2) {
+	if target != m.bit {
+		panic("gate acts on more than one qubit")
+	}
+	res := *mat
+	res.Mul(&m.m)
+	m.m = res
+}
+
+func (m *matrixComputer) CNot(control, target int) {
+	panic("gate acts on more than one qubit")
+}
@@ -0,0 +1,112 @@
+package quantum
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func ExampleOptimize() {
+	circuit := Circuit{
+		&HGate{Bit: 0},
+		&TGate{Bit: 1},
+		&CNotGate{Control: 1, Target: 0},
+		&TGate{Bit: 1},
+		&HGate{Bit: 0},
+		&HGate{Bit: 0},
+		&CNotGate{Control: 1, Target: 0},
+		&ZGate{Bit: 1},
+	}
+	optimized, stats := Optimize(2, circuit)
+	fmt.Println(optimized)
+	fmt.Println(stats.Before, stats.After)
+
+	// Output:
+	// H(0) S*(1)
+	// 8 2
+}
+
+func TestOptimize(t *testing.T) {
+	tests := map[string]func(c Computer){
+		"CCNot": func(c Computer) {
+			CCNot(c, 0, 1, 2)
+			CCNot(c, 0, 1, 2)
+		},
+		"Conj": func(c Computer) {
+			Conj(c, func(c Computer) {
+				CSwap(c, 0, 1, 2)
+				H(c, 3)
+			}, func(c Computer) {
+				CH(c, 3, 4)
+			})
+		},
+		"CUnitary": func(c Computer) {
+			m := RandomMatrix2()
+			CUnitary(c, 0, 1, &m)
+			m.ConjTranspose()
+			CUnitary(c, 0, 1, &m)
+		},
+		"ModAdd": func(c Computer) {
+			ModAdd(c, Reg{0, 1}, Reg{2, 3}, Reg{4, 5}, 6)
+		},
+		"SqrtT": func(c Computer) {
+			SqrtT(c, 0)
+			c.CNot(0, 1)
+			SqrtT(c, 0)
+			InvS(c, 0)
+			InvSqrtT(c, 2)
+			SqrtT(c, 2)
+		},
+	}
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			circuit := Record(7, f)
+			optimized, stats := Optimize(7, circuit)
+			if stats.Before != len(circuit) || stats.After != len(optimized) {
+				t.Fatalf("unexpected stats %v", stats)
+			}
+			if stats.After > stats.Before {
+				t.Fatalf("circuit grew from %d to %d gates", stats.Before, stats.After)
+			}
+			for i := 0; i < 5; i++ {
+				s1 := RandomSimulation(7)
+				s2 := s1.Copy()
+				circuit.Apply(s1)
+				optimized.Apply(s2)
+				if !s1.ApproxEqual(s2, 1e-8) {
+					t.Fatal("incorrect result")
+				}
+			}
+		})
+	}
+}
+
+func TestOptimizeCancels(t *testing.T) {
+	circuit := Record(5, randomizedCircuit(rand.Perm(5), rand.Int(), 30).Apply)
+	doubled := append(append(Circuit{}, circuit...), circuit.Inverse().(Circuit)...)
+	optimized, _ := Optimize(5, doubled)
+	if len(optimized) != 0 {
+		t.Errorf("expected empty circuit but got %v", optimized)
+	}
+}
+
+func TestOptimizeMergeOrder(t *testing.T) {
+	m := RandomMatrix2()
+	circuits := []Circuit{
+		{&HGate{Bit: 0}, &TGate{Bit: 0}, &CNotGate{Control: 0, Target: 1},
+			&UnitaryGate{Bit: 0, Matrix: m}},
+		{&TGate{Bit: 0}, &CNotGate{Control: 0, Target: 1}, &HGate{Bit: 0},
+			&CNotGate{Control: 0, Target: 1}},
+		{&UnitaryGate{Bit: 1, Matrix: m}, &HGate{Bit: 0}, &CNotGate{Control: 1, Target: 0},
+			&HGate{Bit: 1}},
+		{&SGate{Bit: 1}, &CNotGate{Control: 1, Target: 0}, &UnitaryGate{Bit: 1, Matrix: m}},
+	}
+	for i, circuit := range circuits {
+		optimized, _ := Optimize(2, circuit)
+		expected := ExtractUnitary(2, circuit)
+		actual := ExtractUnitary(2, optimized)
+		if !expected.ApproxEqual(actual, 1e-8) {
+			t.Errorf("circuit %d: optimized %v to %v", i, circuit, optimized)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package quantum
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Param is a linear combination of named symbols plus a
+// constant, used as the angle of a ParamGate.
+//
+// The zero value is the constant 0.
+type Param struct {
+	Const  float64
+	Coeffs map[string]float64
+}
+
+// NewParam creates a Param for a single symbol.
+func NewParam(name string) Param {
+	return Param{Coeffs: map[string]float64{name: 1}}
+}
+
+// NewConstParam creates a Param with a constant value.
+func NewConstParam(value float64) Param {
+	return Param{Const: value}
+}
+
+// Add computes the sum of two parameters.
+func (p Param) Add(other Param) Param {
+	res := Param{Const: p.Const + other.Const, Coeffs: map[string]float64{}}
+	for _, coeffs := range []map[string]float64{p.Coeffs, other.Coeffs} {
+		for name, coeff := range coeffs {
+			res.Coeffs[name] += coeff
+		}
+	}
+	return res.simplify()
+}
+
+// Scale multiplies the parameter by a constant.
+func (p Param) Scale(s float64) Param {
+	res := Param{Const: p.Const * s, Coeffs: map[string]float64{}}
+	for name, coeff := range p.Coeffs {
+		res.Coeffs[name] = coeff * s
+	}
+	return res.simplify()
+}
+
+// Bind substitutes values for some or all of the symbols.
+func (p Param) Bind(values map[string]float64) Param {
+	res := Param{Const: p.Const, Coeffs: map[string]float64{}}
+	for name, coeff := range p.Coeffs {
+		if value, ok := values[name]; ok {
+			res.Const += coeff * value
+		} else {
+			res.Coeffs[name] = coeff
+		}
+	}
+	return res.simplify()
+}
+
+// Symbols gets the sorted names of the unbound symbols.
+func (p Param) Symbols() []string {
+	var names []string
+	for name := range p.Coeffs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Value gets the value of the parameter, or returns false
+// if it has unbound symbols.
+func (p Param) Value() (float64, bool) {
+	if len(p.Coeffs) > 0 {
+		return 0, false
+	}
+	return p.Const, true
+}
+
+// String formats the parameter as an expression such as
+// "2*theta - phi + 0.5", which can be read back by
+// ParseCircuit.
+func (p Param) String() string {
+	var res strings.Builder
+	addTerm := func(coeff float64, name string) {
+		if res.Len() > 0 {
+			if coeff < 0 {
+				res.WriteString(" - ")
+				coeff = -coeff
+			} else {
+				res.WriteString(" + ")
+			}
+		}
+		if name == "" {
+			res.WriteString(formatAngle(coeff))
+		} else if coeff == 1 {
+			res.WriteString(name)
+		} else if coeff == -1 {
+			res.WriteString("-" + name)
+		} else {
+			res.WriteString(formatAngle(coeff) + "*" + name)
+		}
+	}
+	for _, name := range p.Symbols() {
+		addTerm(p.Coeffs[name], name)
+	}
+	if res.Len() == 0 || p.Const != 0 {
+		addTerm(p.Const, "")
+	}
+	return res.String()
+}
+
+func (p Param) simplify() Param {
+	for name, coeff := range p.Coeffs {
+		if coeff == 0 {
+			delete(p.Coeffs, name)
+		}
+	}
+	if len(p.Coeffs) == 0 {
+		p.Coeffs = nil
+	}
+	return p
+}
+
+// A ParamGate is a rotation gate whose angles are Params.
+//
+// The Kind is "RX", "RY", "RZ", "Phase", or "U3", and the
+// angles are in the same order as the fields of the
+// corresponding gate (e.g. U3Gate).
+//
+// A ParamGate must be bound with Circuit.Bind before it
+// is applied, or else Apply panics. Circuit.ApplyBound
+// returns an error for unbound symbols instead.
+type ParamGate struct {
+	Kind   string
+	Bit    int
+	Angles []Param
+}
+
+func (p *ParamGate) String() string {
+	parts := make([]string, len(p.Angles))
+	for i, angle := range p.Angles {
+		parts[i] = angle.String()
+	}
+	return fmt.Sprintf("%s(%d, %s)", p.Kind, p.Bit, strings.Join(parts, ", "))
+}
+
+func (p *ParamGate) Apply(c Computer) {
+	values := make([]float64, len(p.Angles))
+	for i, angle := range p.Angles {
+		value, ok := angle.Value()
+		if !ok {
+			panic("unbound parameter: " + angle.Symbols()[0])
+		}
+		values[i] = value
+	}
+	rotationGate(p.Kind, p.Bit, values).Apply(c)
+}
+
+func (p *ParamGate) Inverse() Gate {
+	angles := make([]Param, len(p.Angles))
+	for i, angle := range p.Angles {
+		angles[i] = angle.Scale(-1)
+	}
+	if p.Kind == "U3" {
+		angles[1], angles[2] = angles[2], angles[1]
+	}
+	return &ParamGate{Kind: p.Kind, Bit: p.Bit, Angles: angles}
+}
+
+// FreeParams gets the sorted names of the symbols in the
+// gate that have not been bound.
+func (p *ParamGate) FreeParams() []string {
+	var names []string
+	for _, angle := range p.Angles {
+		names = append(names, angle.Symbols()...)
+	}
+	return uniqueSorted(names)
+}
+
+// Bind substitutes values for symbols. If every symbol is
+// bound, the corresponding rotation gate is returned.
+func (p *ParamGate) Bind(values map[string]float64) Gate {
+	angles := make([]Param, len(p.Angles))
+	constants := make([]float64, len(p.Angles))
+	allBound := true
+	for i, angle := range p.Angles {
+		angles[i] = angle.Bind(values)
+		if value, ok := angles[i].Value(); ok {
+			constants[i] = value
+		} else {
+			allBound = false
+		}
+	}
+	if allBound {
+		return rotationGate(p.Kind, p.Bit, constants)
+	}
+	return &ParamGate{Kind: p.Kind, Bit: p.Bit, Angles: angles}
+}
+
+// FreeParams gets the sorted names of the symbols in the
+// circuit that have not been bound.
+func (c Circuit) FreeParams() []string {
+	var names []string
+	for _, g := range c {
+		names = append(names, gateFreeParams(g)...)
+	}
+	return uniqueSorted(names)
+}
+
+// Bind creates a new circuit where symbols are replaced
+// by their values. Symbols missing from values remain
+// free, so that circuits can be bound in stages.
+//
+// Applying a circuit with free symbols panics.
+func (c Circuit) Bind(values map[string]float64) Circuit {
+	res := make(Circuit, len(c))
+	for i, g := range c {
+		res[i] = bindGate(g, values)
+	}
+	return res
+}
+
+// ApplyBound binds the circuit and applies it to a
+// Computer. If any symbols are left unbound, an error is
+// returned and nothing is applied.
+func (c Circuit) ApplyBound(comp Computer, values map[string]float64) error {
+	bound := c.Bind(values)
+	if free := bound.FreeParams(); len(free) > 0 {
+		return fmt.Errorf("apply circuit: unbound parameters: %s", strings.Join(free, ", "))
+	}
+	bound.Apply(comp)
+	return nil
+}
+
+func gateFreeParams(g Gate) []string {
+	switch g := g.(type) {
+	case *ParamGate:
+		return g.FreeParams()
+	case *ControlledGate:
+		return gateFreeParams(g.Gate)
+	case Circuit:
+		return g.FreeParams()
+	}
+	return nil
+}
+
+func bindGate(g Gate, values map[string]float64) Gate {
+	switch g := g.(type) {
+	case *ParamGate:
+		return g.Bind(values)
+	case *ControlledGate:
+		return &ControlledGate{Control: g.Control, Gate: bindGate(g.Gate, values)}
+	case Circuit:
+		return g.Bind(values)
+	}
+	return g
+}
+
+func uniqueSorted(names []string) []string {
+	sort.Strings(names)
+	var res []string
+	for i, name := range names {
+		if i == 0 || names[i-1] != name {
+			res = append(res, name)
+		}
+	}
+	return res
+}
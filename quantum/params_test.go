@@ -0,0 +1,160 @@
+package quantum
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParamString(t *testing.T) {
+	theta := NewParam("theta")
+	phi := NewParam("phi")
+	testCases := map[string]Param{
+		"0":                     {},
+		"-1.5":                  NewConstParam(-1.5),
+		"theta":                 theta,
+		"-theta":                theta.Scale(-1),
+		"-phi + 2*theta + 0.5":  theta.Scale(2).Add(phi.Scale(-1)).Add(NewConstParam(0.5)),
+		"1e-20*phi - theta - 3": phi.Scale(1e-20).Add(theta.Scale(-1)).Add(NewConstParam(-3)),
+		"phi":                   theta.Add(phi).Add(theta.Scale(-1)),
+		"0.25*theta + 1.5e+100": theta.Scale(0.25).Add(NewConstParam(1.5e100)),
+	}
+	for expected, param := range testCases {
+		if actual := param.String(); actual != expected {
+			t.Errorf("expected %s but got %s", expected, actual)
+		}
+		gate := &ParamGate{Kind: "RZ", Bit: 1, Angles: []Param{param}}
+		parsed, err := ParseCircuit(gate.String())
+		if err != nil {
+			t.Error(err)
+		} else if parsed.String() != gate.String() {
+			t.Errorf("expected %s but got %s", gate, parsed)
+		}
+	}
+}
+
+func TestParamBind(t *testing.T) {
+	param := NewParam("a").Scale(2).Add(NewParam("b")).Add(NewConstParam(1))
+	partial := param.Bind(map[string]float64{"a": 3})
+	if partial.String() != "b + 7" {
+		t.Errorf("unexpected partial binding: %s", partial)
+	}
+	if _, ok := partial.Value(); ok {
+		t.Error("partial binding should have no value")
+	}
+	value, ok := partial.Bind(map[string]float64{"b": -2, "c": 4}).Value()
+	if !ok || value != 5 {
+		t.Errorf("unexpected value: %f (%v)", value, ok)
+	}
+}
+
+func TestCircuitBind(t *testing.T) {
+	theta := NewParam("theta")
+	phi := NewParam("phi")
+	circuit := Circuit{
+		&HGate{Bit: 0},
+		&ParamGate{Kind: "RX", Bit: 1, Angles: []Param{theta.Scale(2)}},
+		&ControlledGate{Control: 0, Gate: &ParamGate{Kind: "Phase", Bit: 2,
+			Angles: []Param{phi}}},
+		Circuit{
+			&ParamGate{Kind: "U3", Bit: 2, Angles: []Param{theta, phi, NewConstParam(0.5)}},
+		},
+	}
+	if names := circuit.FreeParams(); !reflect.DeepEqual(names, []string{"phi", "theta"}) {
+		t.Fatalf("unexpected free params: %v", names)
+	}
+
+	partial := circuit.Bind(map[string]float64{"theta": 0.3})
+	if names := partial.FreeParams(); !reflect.DeepEqual(names, []string{"phi"}) {
+		t.Fatalf("unexpected free params: %v", names)
+	}
+	if _, ok := partial[1].(*RXGate); !ok {
+		t.Errorf("expected RXGate but got %s", partial[1])
+	}
+	if names := circuit.FreeParams(); len(names) != 2 {
+		t.Fatal("binding modified the original circuit")
+	}
+
+	bound := partial.Bind(map[string]float64{"phi": -1.2})
+	if names := bound.FreeParams(); len(names) != 0 {
+		t.Fatalf("unexpected free params: %v", names)
+	}
+	expected := Circuit{
+		&HGate{Bit: 0},
+		&RXGate{Bit: 1, Theta: 0.6},
+		&ControlledGate{Control: 0, Gate: &PhaseGate{Bit: 2, Phi: -1.2}},
+		&U3Gate{Bit: 2, Theta: 0.3, Phi: -1.2, Lambda: 0.5},
+	}
+	if !ExtractUnitary(3, bound).ApproxEqual(ExtractUnitary(3, expected), 1e-8) {
+		t.Error("incorrect bound circuit")
+	}
+	inverse := Circuit{circuit, circuit.Inverse()}.Bind(map[string]float64{
+		"theta": 0.3,
+		"phi":   -1.2,
+	})
+	if !ExtractUnitary(3, inverse).ApproxEqual(NewMatrix(3), 1e-8) {
+		t.Error("incorrect inverse")
+	}
+}
+
+func TestParamGateUnbound(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "unbound parameter: theta" {
+			t.Errorf("unexpected panic: %v", r)
+		}
+	}()
+	circuit := Circuit{
+		&ParamGate{Kind: "RY", Bit: 0, Angles: []Param{NewParam("theta")}},
+	}
+	circuit.Apply(NewSimulation(1))
+}
+
+func TestCircuitApplyBound(t *testing.T) {
+	theta := NewParam("theta")
+	circuit := Circuit{
+		&ParamGate{Kind: "RY", Bit: 0, Angles: []Param{theta}},
+		&ControlledGate{Control: 0, Gate: &ParamGate{Kind: "RZ", Bit: 1,
+			Angles: []Param{NewParam("phi").Add(theta)}}},
+	}
+	s := NewSimulation(2)
+	err := circuit.ApplyBound(s, map[string]float64{"theta": 0.5})
+	if err == nil || !strings.Contains(err.Error(), "unbound parameters: phi") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !s.ApproxEqual(NewSimulation(2), 0) {
+		t.Error("state changed despite error")
+	}
+
+	values := map[string]float64{"theta": 0.5, "phi": -0.2}
+	if err := circuit.ApplyBound(s, values); err != nil {
+		t.Fatal(err)
+	}
+	expected := NewSimulation(2)
+	circuit.Bind(values).Apply(expected)
+	if !s.ApproxEqual(expected, 1e-8) {
+		t.Error("incorrect result")
+	}
+}
+
+func TestParamGateJSON(t *testing.T) {
+	circuit := Circuit{
+		&ParamGate{Kind: "U3", Bit: 1, Angles: []Param{
+			NewParam("x").Scale(math.Pi),
+			NewConstParam(0.25),
+			NewParam("y").Add(NewParam("x")),
+		}},
+	}
+	data, err := json.Marshal(circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Circuit
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != circuit.String() {
+		t.Errorf("expected %s but got %s", circuit, decoded)
+	}
+}
@@ -0,0 +1,400 @@
+package quantum
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseCircuit decodes a circuit from the format produced
+// by Circuit.String(), such as "H(0) CNot(0, 1) T*(2)".
+//
+// Inv(...) is supported when its contents can be parsed,
+// in which case it produces the inverse of the contents.
+// Likewise, C(control, ...) produces a ControlledGate.
+// Rotations with symbolic angles, such as RZ(0, 2*theta),
+// produce ParamGates.
+// Gates with arbitrary strings, such as FnGates and
+// ClassicalGates, cannot be parsed.
+func ParseCircuit(s string) (Circuit, error) {
+	p := &circuitParser{source: s}
+	res, err := p.parseCircuit()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.source[p.pos])
+	}
+	return res, nil
+}
+
+type circuitParser struct {
+	source string
+	pos    int
+}
+
+func (p *circuitParser) done() bool {
+	p.skipSpace()
+	return p.pos >= len(p.source)
+}
+
+func (p *circuitParser) skipSpace() {
+	for p.pos < len(p.source) && unicode.IsSpace(rune(p.source[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *circuitParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("parse circuit: offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *circuitParser) expect(b byte) error {
+	p.skipSpace()
+	if p.pos >= len(p.source) {
+		return p.errorf("expected %q but got end of input", b)
+	} else if p.source[p.pos] != b {
+		return p.errorf("expected %q but got %q", b, p.source[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+// parseCircuit parses gates until the end of the input
+// or a closing parenthesis.
+func (p *circuitParser) parseCircuit() (Circuit, error) {
+	var res Circuit
+	for !p.done() && p.source[p.pos] != ')' {
+		g, err := p.parseGate()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, g)
+	}
+	return res, nil
+}
+
+func (p *circuitParser) parseGate() (Gate, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.source) && (unicode.IsLetter(rune(p.source[p.pos])) ||
+		unicode.IsDigit(rune(p.source[p.pos]))) {
+		p.pos++
+	}
+	name := p.source[start:p.pos]
+	if name == "" {
+		return nil, p.errorf("expected gate name")
+	}
+	conj := false
+	if p.pos < len(p.source) && p.source[p.pos] == '*' {
+		conj = true
+		p.pos++
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+
+	if name == "Inv" && !conj {
+		inner, err := p.parseCircuit()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		if len(inner) == 1 {
+			return inner[0].Inverse(), nil
+		}
+		return inner.Inverse(), nil
+	} else if name == "U" && !conj {
+		return p.parseUnitaryGate()
+	} else if name == "C" && !conj {
+		return p.parseControlledGate()
+	} else if numAngles, ok := rotationGateAngles(name); ok && !conj {
+		return p.parseRotationGate(name, numAngles)
+	}
+
+	args, err := p.parseInts()
+	if err != nil {
+		return nil, err
+	}
+	res, numArgs := circuitParserGate(name, conj, args)
+	if res == nil {
+		return nil, fmt.Errorf("parse circuit: offset %d: unknown gate %s", start, name)
+	} else if len(args) != numArgs {
+		return nil, fmt.Errorf("parse circuit: offset %d: %s expects %d arguments", start, name,
+			numArgs)
+	}
+	return res, nil
+}
+
+// circuitParserGate creates a gate from its name and the
+// qubits it acts on, and returns the number of qubits it
+// expects. If too few args are given, qubit 0 is used in
+// their place.
+func circuitParserGate(name string, conj bool, args []int) (Gate, int) {
+	arg := func(i int) int {
+		if i < len(args) {
+			return args[i]
+		}
+		return 0
+	}
+	if conj {
+		switch name {
+		case "T":
+			return &TGate{Bit: arg(0), Conjugate: true}, 1
+		case "S":
+			return &SGate{Bit: arg(0), Conjugate: true}, 1
+		case "SqrtT":
+			return &SqrtTGate{Bit: arg(0), Conjugate: true}, 1
+		case "SqrtNot":
+			return &SqrtNotGate{Bit: arg(0), Invert: true}, 1
+		case "CSqrtNot":
+			return &CSqrtNotGate{Control: arg(0), Target: arg(1), Invert: true}, 2
+		}
+		return nil, 0
+	}
+	switch name {
+	case "H":
+		return &HGate{Bit: arg(0)}, 1
+	case "T":
+		return &TGate{Bit: arg(0)}, 1
+	case "S":
+		return &SGate{Bit: arg(0)}, 1
+	case "X":
+		return &XGate{Bit: arg(0)}, 1
+	case "Y":
+		return &YGate{Bit: arg(0)}, 1
+	case "Z":
+		return &ZGate{Bit: arg(0)}, 1
+	case "SqrtT":
+		return &SqrtTGate{Bit: arg(0)}, 1
+	case "SqrtNot":
+		return &SqrtNotGate{Bit: arg(0)}, 1
+	case "Measure":
+		return &MeasureGate{Bit: arg(0)}, 1
+	case "CH":
+		return &CHGate{Control: arg(0), Target: arg(1)}, 2
+	case "CNot":
+		return &CNotGate{Control: arg(0), Target: arg(1)}, 2
+	case "CSqrtNot":
+		return &CSqrtNotGate{Control: arg(0), Target: arg(1)}, 2
+	case "Swap":
+		return &SwapGate{A: arg(0), B: arg(1)}, 2
+	case "CCNot":
+		return &CCNotGate{Control1: arg(0), Control2: arg(1), Target: arg(2)}, 3
+	case "CSwap":
+		return &CSwapGate{Control: arg(0), A: arg(1), B: arg(2)}, 3
+	}
+	return nil, 0
+}
+
+// parseInts parses a comma-separated list of qubit
+// indices followed by a closing parenthesis.
+func (p *circuitParser) parseInts() ([]int, error) {
+	var res []int
+	for {
+		p.skipSpace()
+		start := p.pos
+		for p.pos < len(p.source) && unicode.IsDigit(rune(p.source[p.pos])) {
+			p.pos++
+		}
+		if start == p.pos {
+			return nil, p.errorf("expected qubit index")
+		}
+		n, err := strconv.Atoi(p.source[start:p.pos])
+		if err != nil {
+			return nil, p.errorf("invalid qubit index: %s", err)
+		}
+		res = append(res, n)
+		p.skipSpace()
+		if p.pos < len(p.source) && p.source[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		return res, p.expect(')')
+	}
+}
+
+func (p *circuitParser) parseInt() (int, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.source) && unicode.IsDigit(rune(p.source[p.pos])) {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.source[start:p.pos])
+	if err != nil {
+		return 0, p.errorf("expected qubit index")
+	}
+	return n, nil
+}
+
+func (p *circuitParser) parseFloat() (float64, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.source) {
+		b := p.source[p.pos]
+		if (b == '+' || b == '-') && p.pos > start {
+			if prev := p.source[p.pos-1]; prev != 'e' && prev != 'E' {
+				break
+			}
+		} else if !strings.ContainsRune("0123456789.eE+-", rune(b)) {
+			break
+		}
+		p.pos++
+	}
+	f, err := strconv.ParseFloat(p.source[start:p.pos], 64)
+	if err != nil {
+		return 0, p.errorf("expected angle")
+	}
+	return f, nil
+}
+
+func rotationGateAngles(name string) (int, bool) {
+	switch name {
+	case "RX", "RY", "RZ", "Phase":
+		return 1, true
+	case "U3":
+		return 3, true
+	}
+	return 0, false
+}
+
+func (p *circuitParser) parseRotationGate(name string, numAngles int) (Gate, error) {
+	bit, err := p.parseInt()
+	if err != nil {
+		return nil, err
+	}
+	angles := make([]Param, numAngles)
+	values := make([]float64, numAngles)
+	allConst := true
+	for i := range angles {
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		if angles[i], err = p.parseParam(); err != nil {
+			return nil, err
+		}
+		var ok bool
+		if values[i], ok = angles[i].Value(); !ok {
+			allConst = false
+		}
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	if !allConst {
+		return &ParamGate{Kind: name, Bit: bit, Angles: angles}, nil
+	}
+	return rotationGate(name, bit, values), nil
+}
+
+// parseParam parses a linear expression such as
+// "2*theta - phi + 0.5".
+func (p *circuitParser) parseParam() (Param, error) {
+	var res Param
+	first := true
+	for {
+		p.skipSpace()
+		sign := 1.0
+		if !first {
+			if p.pos >= len(p.source) || (p.source[p.pos] != '+' && p.source[p.pos] != '-') {
+				return res, nil
+			}
+			if p.source[p.pos] == '-' {
+				sign = -1
+			}
+			p.pos++
+			p.skipSpace()
+		} else if p.pos < len(p.source) && p.source[p.pos] == '-' {
+			sign = -1
+			p.pos++
+		}
+		first = false
+
+		var term Param
+		if p.pos < len(p.source) && isParamNameStart(p.source[p.pos]) {
+			term = NewParam(p.parseParamName())
+		} else {
+			value, err := p.parseFloat()
+			if err != nil {
+				return res, err
+			}
+			term = NewConstParam(value)
+			p.skipSpace()
+			if p.pos < len(p.source) && p.source[p.pos] == '*' {
+				p.pos++
+				p.skipSpace()
+				if p.pos >= len(p.source) || !isParamNameStart(p.source[p.pos]) {
+					return res, p.errorf("expected parameter name")
+				}
+				term = NewParam(p.parseParamName()).Scale(value)
+			}
+		}
+		res = res.Add(term.Scale(sign))
+	}
+}
+
+func (p *circuitParser) parseParamName() string {
+	start := p.pos
+	for p.pos < len(p.source) && (isParamNameStart(p.source[p.pos]) ||
+		unicode.IsDigit(rune(p.source[p.pos]))) {
+		p.pos++
+	}
+	return p.source[start:p.pos]
+}
+
+func isParamNameStart(b byte) bool {
+	return b == '_' || unicode.IsLetter(rune(b))
+}
+
+func (p *circuitParser) parseControlledGate() (Gate, error) {
+	control, err := p.parseInt()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	inner, err := p.parseCircuit()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	if len(inner) == 1 {
+		return &ControlledGate{Control: control, Gate: inner[0]}, nil
+	}
+	return &ControlledGate{Control: control, Gate: inner}, nil
+}
+
+func (p *circuitParser) parseUnitaryGate() (Gate, error) {
+	bit, err := p.parseInt()
+	if err != nil {
+		return nil, err
+	}
+	var entries [4]complex128
+	for i := range entries {
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		end := strings.IndexByte(p.source[p.pos:], ')')
+		if end == -1 {
+			return nil, p.errorf("expected complex number")
+		}
+		entries[i], err = strconv.ParseComplex(p.source[p.pos:p.pos+end+1], 128)
+		if err != nil {
+			return nil, p.errorf("invalid complex number: %s", err)
+		}
+		p.pos += end + 1
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return &UnitaryGate{
+		Bit:    bit,
+		Matrix: Matrix2{entries[0], entries[1], entries[2], entries[3]},
+	}, nil
+}
@@ -0,0 +1,76 @@
+package quantum
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseCircuit(t *testing.T) {
+	circuit := Circuit{
+		&HGate{Bit: 0},
+		&CHGate{Control: 1, Target: 2},
+		&TGate{Bit: 1},
+		&TGate{Bit: 2, Conjugate: true},
+		&SGate{Bit: 0, Conjugate: true},
+		&XGate{Bit: 3},
+		&YGate{Bit: 2},
+		&ZGate{Bit: 1},
+		&CNotGate{Control: 3, Target: 0},
+		&CCNotGate{Control1: 0, Control2: 2, Target: 1},
+		&SqrtNotGate{Bit: 1, Invert: true},
+		&CSqrtNotGate{Control: 1, Target: 2, Invert: true},
+		&CSqrtNotGate{Control: 2, Target: 1},
+		&SqrtTGate{Bit: 3, Conjugate: true},
+		&SwapGate{A: 0, B: 3},
+		&CSwapGate{Control: 2, A: 3, B: 1},
+		&UnitaryGate{Bit: 1, Matrix: RandomMatrix2()},
+		&MeasureGate{Bit: 2},
+	}
+	parsed, err := ParseCircuit(circuit.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != circuit.String() {
+		t.Fatalf("expected %s but got %s", circuit, parsed)
+	}
+	if parsed[16].(*UnitaryGate).Matrix != circuit[16].(*UnitaryGate).Matrix {
+		t.Fatal("unitary was not preserved exactly")
+	}
+
+	random := randomizedCircuit(rand.Perm(5), rand.Int(), 30)
+	parsed, err = ParseCircuit(random.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != random.String() {
+		t.Fatalf("expected %s but got %s", random, parsed)
+	}
+}
+
+func TestParseCircuitInv(t *testing.T) {
+	parsed, err := ParseCircuit("Inv(T(0))  Inv(H(1) CNot(1, 2) SqrtNot*(2))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "T*(0) SqrtNot(2) CNot(1, 2) H(1)"
+	if parsed.String() != expected {
+		t.Fatalf("expected %s but got %s", expected, parsed)
+	}
+}
+
+func TestParseCircuitErrors(t *testing.T) {
+	for _, source := range []string{
+		"H(0",
+		"H(0, 1)",
+		"Foo(1)",
+		"CNot*(1, 2)",
+		"H(0))",
+		"Inv(Fn)",
+		"U(0, (1+0i), (0+0i))",
+		"T(x)",
+	} {
+		if _, err := ParseCircuit(source); err == nil {
+			t.Errorf("expected error for %q", source)
+		}
+	}
+}
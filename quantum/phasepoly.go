@@ -0,0 +1,110 @@
+package quantum
+
+import "encoding/binary"
+
+// OptimizePhasePoly reduces the number of T gates in a
+// circuit using phase folding.
+//
+// Within regions of CNot, T, T*, S, S*, and Z gates, the
+// state of each qubit is a parity (XOR) of some path
+// variables, and every phase gate applies a phase that
+// depends only on the parity of the qubit it acts on.
+// Phase gates that act on equal parities are merged into
+// one, which is applied where the parity first occurs.
+// Other gates start new regions by assigning a fresh path
+// variable to the qubits they act on.
+//
+// The circuit must not perform measurements, and every
+// gate must support any Computer.
+func OptimizePhasePoly(numBits int, c Circuit) (Circuit, *OptimizeStats) {
+	recorded := Record(numBits, c.Apply)
+	stats := &OptimizeStats{Before: len(recorded)}
+
+	gates := make([]Gate, len(recorded))
+	for i, g := range recorded {
+		gates[i] = toPhaseGate(g)
+	}
+
+	parities := make([]phaseParity, numBits)
+	for i := range parities {
+		parities[i] = newPhaseParity(i)
+	}
+	numVars := numBits
+
+	type phaseTerm struct {
+		index   int
+		eighths int
+	}
+	terms := map[string]*phaseTerm{}
+	firstTerms := map[int]*phaseTerm{}
+	removed := make([]bool, len(gates))
+
+	for i, g := range gates {
+		switch g := g.(type) {
+		case *CNotGate:
+			parities[g.Target] = parities[g.Target].Xor(parities[g.Control])
+		case *phaseGate:
+			key := parities[g.Bit].Key()
+			if term, ok := terms[key]; ok {
+				term.eighths += g.Eighths
+				removed[i] = true
+			} else {
+				term := &phaseTerm{index: i, eighths: g.Eighths}
+				terms[key] = term
+				firstTerms[i] = term
+			}
+		default:
+			parities[singleGateBit(g)] = newPhaseParity(numVars)
+			numVars++
+		}
+	}
+
+	var res Circuit
+	for i, g := range gates {
+		if removed[i] {
+			continue
+		}
+		if p, ok := g.(*phaseGate); ok {
+			res = append(res, phaseGates(p.Bit, firstTerms[i].eighths)...)
+		} else {
+			res = append(res, g)
+		}
+	}
+	stats.After = len(res)
+	return res, stats
+}
+
+// A phaseParity is a bitset of path variables whose XOR
+// gives the value of a qubit.
+type phaseParity []uint64
+
+func newPhaseParity(variable int) phaseParity {
+	res := make(phaseParity, variable/64+1)
+	res[variable/64] = 1 << uint(variable%64)
+	return res
+}
+
+// Xor computes the parity p^p1 as a new bitset.
+func (p phaseParity) Xor(p1 phaseParity) phaseParity {
+	if len(p1) > len(p) {
+		p, p1 = p1, p
+	}
+	res := append(phaseParity{}, p...)
+	for i, x := range p1 {
+		res[i] ^= x
+	}
+	return res
+}
+
+// Key produces a map key which is equal for two
+// bitsets if and only if they are equal.
+func (p phaseParity) Key() string {
+	for len(p) > 0 && p[len(p)-1] == 0 {
+		p = p[:len(p)-1]
+	}
+	data := make([]byte, 8*len(p))
+	for i, x := range p {
+		binary.LittleEndian.PutUint64(data[8*i:], x)
+	}
+	return string(data)
+}
@@ -0,0 +1,68 @@
+package quantum
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleOptimizePhasePoly() {
+	circuit := Circuit{
+		&TGate{Bit: 0},
+		&CNotGate{Control: 0, Target: 1},
+		&TGate{Bit: 1},
+		&CNotGate{Control: 0, Target: 1},
+		&CNotGate{Control: 1, Target: 0},
+		&TGate{Bit: 0},
+		&HGate{Bit: 1},
+		&TGate{Bit: 1},
+	}
+	optimized, _ := OptimizePhasePoly(2, circuit)
+	fmt.Println(optimized)
+
+	// Output:
+	// T(0) CNot(0, 1) S(1) CNot(0, 1) CNot(1, 0) H(1) T(1)
+}
+
+func TestOptimizePhasePoly(t *testing.T) {
+	tests := map[string]func(c Computer){
+		"CCNot": func(c Computer) {
+			CCNot(c, 0, 1, 2)
+			CCNot(c, 1, 0, 2)
+		},
+		"ToffoliN": func(c Computer) {
+			ToffoliN(c, 0, 1, 2, 3, 4)
+		},
+		"Add": func(c Computer) {
+			carry := 6
+			Add(c, Reg{0, 1, 2}, Reg{3, 4, 5}, &carry)
+		},
+		"ModAdd": func(c Computer) {
+			ModAdd(c, Reg{0, 1}, Reg{2, 3}, Reg{4, 5}, 6)
+		},
+	}
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			circuit := Record(7, f)
+			optimized, stats := OptimizePhasePoly(7, circuit)
+			if stats.Before != len(circuit) || stats.After != len(optimized) {
+				t.Fatalf("unexpected stats %v", stats)
+			}
+			before := NewResourceCounter(7)
+			after := NewResourceCounter(7)
+			circuit.Apply(before)
+			optimized.Apply(after)
+			if after.TCount() > before.TCount() {
+				t.Fatalf("T-count grew from %d to %d", before.TCount(), after.TCount())
+			}
+			for i := 0; i < 5; i++ {
+				s1 := RandomSimulation(7)
+				s2 := s1.Copy()
+				circuit.Apply(s1)
+				optimized.Apply(s2)
+				if !s1.ApproxEqual(s2, 1e-8) {
+					t.Fatal("incorrect result")
+				}
+			}
+		})
+	}
+}
@@ -26,6 +26,16 @@ func InvT(c Computer, bitIdx int) {
 	c.Unitary(bitIdx, &Matrix2{1, 0, 0, invTGateValue})
 }
 
+// S performs a rotation by pi/2.
+func S(c Computer, bitIdx int) {
+	c.Unitary(bitIdx, &Matrix2{1, 0, 0, 1i})
+}
+
+// InvS performs an inverse rotation by pi/2.
+func InvS(c Computer, bitIdx int) {
+	c.Unitary(bitIdx, &Matrix2{1, 0, 0, -1i})
+}
+
 // SqrtT performs the positive square root of the T gate.
 func SqrtT(c Computer, bitIdx int) {
 	c.Unitary(bitIdx, &Matrix2{1, 0, 0, cmplx.Exp(complex(0, math.Pi/8))})
@@ -4,11 +4,12 @@ import (
 	"fmt"
 	"math"
 	"math/cmplx"
+	"runtime"
 	"testing"
 )
 
 func BenchmarkUnitary(b *testing.B) {
-	for _, size := range []int{1, 5, 10} {
+	for _, size := range []int{1, 5, 10, 20} {
 		b.Run(fmt.Sprintf("Bits%d", size), func(b *testing.B) {
 			s := RandomSimulation(size)
 			coeff := complex(1.0/math.Sqrt2, 0)
@@ -17,6 +18,14 @@ func BenchmarkUnitary(b *testing.B) {
 			}
 		})
 	}
+	b.Run("Bits20Parallel", func(b *testing.B) {
+		s := RandomSimulation(20)
+		s.Workers = runtime.GOMAXPROCS(0)
+		coeff := complex(1.0/math.Sqrt2, 0)
+		for i := 0; i < b.N; i++ {
+			s.Unitary(i%20, &Matrix2{coeff, coeff, coeff, -coeff})
+		}
+	})
 }
 
 func BenchmarkT(b *testing.B) {
@@ -31,7 +40,7 @@ func BenchmarkT(b *testing.B) {
 }
 
 func BenchmarkCNot(b *testing.B) {
-	for _, size := range []int{2, 5, 10} {
+	for _, size := range []int{2, 5, 10, 20} {
 		b.Run(fmt.Sprintf("Bits%d", size), func(b *testing.B) {
 			s := RandomSimulation(size)
 			for i := 0; i < b.N; i++ {
@@ -39,6 +48,13 @@ func BenchmarkCNot(b *testing.B) {
 			}
 		})
 	}
+	b.Run("Bits20Parallel", func(b *testing.B) {
+		s := RandomSimulation(20)
+		s.Workers = runtime.GOMAXPROCS(0)
+		for i := 0; i < b.N; i++ {
+			s.CNot(i%20, (i+1)%20)
+		}
+	})
 }
 
 func TestCSqrtNot(t *testing.T) {
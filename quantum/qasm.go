@@ -0,0 +1,189 @@
+package quantum
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+)
+
+// WriteQASM encodes a circuit on numBits qubits as an
+// OpenQASM 2.0 program using the gates from qelib1.inc.
+//
+// Arbitrary unitaries are written as u3 gates, which do
+// not preserve global phase.
+//
+// If the circuit contains measurements, their results
+// are stored in a classical register of the same size.
+//
+// An error is returned if the circuit contains a gate
+// that cannot be expressed in QASM, such as an FnGate or
+// a ClassicalGate.
+func WriteQASM(w io.Writer, numBits int, c Circuit) error {
+	var buf bytes.Buffer
+	buf.WriteString("OPENQASM 2.0;\ninclude \"qelib1.inc\";\n")
+	fmt.Fprintf(&buf, "qreg q[%d];\n", numBits)
+	if containsMeasurement(c) {
+		fmt.Fprintf(&buf, "creg c[%d];\n", numBits)
+	}
+	if err := writeQASMGates(&buf, numBits, c); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeQASMGates(w io.Writer, numBits int, c Circuit) error {
+	for _, g := range c {
+		if err := writeQASMGate(w, numBits, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeQASMGate(w io.Writer, numBits int, g Gate) error {
+	switch g := g.(type) {
+	case Circuit:
+		return writeQASMGates(w, numBits, g)
+	case *HGate:
+		fmt.Fprintf(w, "h q[%d];\n", g.Bit)
+	case *TGate:
+		if g.Conjugate {
+			fmt.Fprintf(w, "tdg q[%d];\n", g.Bit)
+		} else {
+			fmt.Fprintf(w, "t q[%d];\n", g.Bit)
+		}
+	case *SGate:
+		if g.Conjugate {
+			fmt.Fprintf(w, "sdg q[%d];\n", g.Bit)
+		} else {
+			fmt.Fprintf(w, "s q[%d];\n", g.Bit)
+		}
+	case *XGate:
+		fmt.Fprintf(w, "x q[%d];\n", g.Bit)
+	case *YGate:
+		fmt.Fprintf(w, "y q[%d];\n", g.Bit)
+	case *ZGate:
+		fmt.Fprintf(w, "z q[%d];\n", g.Bit)
+	case *CNotGate:
+		fmt.Fprintf(w, "cx q[%d],q[%d];\n", g.Control, g.Target)
+	case *CCNotGate:
+		fmt.Fprintf(w, "ccx q[%d],q[%d],q[%d];\n", g.Control1, g.Control2, g.Target)
+	case *SwapGate:
+		fmt.Fprintf(w, "swap q[%d],q[%d];\n", g.A, g.B)
+	case *CSwapGate:
+		fmt.Fprintf(w, "cswap q[%d],q[%d],q[%d];\n", g.Control, g.A, g.B)
+	case *CHGate:
+		fmt.Fprintf(w, "ch q[%d],q[%d];\n", g.Control, g.Target)
+	case *SqrtNotGate:
+		// SqrtNot is H*InvS*H, and its inverse is H*S*H.
+		s := "sdg"
+		if g.Invert {
+			s = "s"
+		}
+		fmt.Fprintf(w, "h q[%d];\n%s q[%d];\nh q[%d];\n", g.Bit, s, g.Bit, g.Bit)
+	case *CSqrtNotGate:
+		return writeQASMGates(w, numBits, Record(numBits, g.Apply))
+	case *SqrtTGate:
+		sign := ""
+		if g.Conjugate {
+			sign = "-"
+		}
+		fmt.Fprintf(w, "u1(%spi/8) q[%d];\n", sign, g.Bit)
+	case *RXGate:
+		fmt.Fprintf(w, "rx(%s) q[%d];\n", formatQASMFloat(g.Theta), g.Bit)
+	case *RYGate:
+		fmt.Fprintf(w, "ry(%s) q[%d];\n", formatQASMFloat(g.Theta), g.Bit)
+	case *RZGate:
+		fmt.Fprintf(w, "rz(%s) q[%d];\n", formatQASMFloat(g.Theta), g.Bit)
+	case *PhaseGate:
+		fmt.Fprintf(w, "u1(%s) q[%d];\n", formatQASMFloat(g.Phi), g.Bit)
+	case *U3Gate:
+		fmt.Fprintf(w, "u3(%s,%s,%s) q[%d];\n", formatQASMFloat(g.Theta), formatQASMFloat(g.Phi),
+			formatQASMFloat(g.Lambda), g.Bit)
+	case *ControlledGate:
+		return writeQASMControlled(w, numBits, g)
+	case *MeasureGate:
+		fmt.Fprintf(w, "measure q[%d] -> c[%d];\n", g.Bit, g.Bit)
+	case *UnitaryGate:
+		theta, phi, lambda := u3Angles(&g.Matrix)
+		fmt.Fprintf(w, "u3(%s,%s,%s) q[%d];\n", formatQASMFloat(theta), formatQASMFloat(phi),
+			formatQASMFloat(lambda), g.Bit)
+	default:
+		return fmt.Errorf("write QASM: cannot export gate %T (%s)", g, g)
+	}
+	return nil
+}
+
+// writeQASMControlled writes a controlled gate, using
+// the controlled gates from qelib1.inc when possible and
+// decomposing it into primitive gates otherwise.
+func writeQASMControlled(w io.Writer, numBits int, g *ControlledGate) error {
+	switch inner := g.Gate.(type) {
+	case *XGate:
+		fmt.Fprintf(w, "cx q[%d],q[%d];\n", g.Control, inner.Bit)
+	case *YGate:
+		fmt.Fprintf(w, "cy q[%d],q[%d];\n", g.Control, inner.Bit)
+	case *ZGate:
+		fmt.Fprintf(w, "cz q[%d],q[%d];\n", g.Control, inner.Bit)
+	case *HGate:
+		fmt.Fprintf(w, "ch q[%d],q[%d];\n", g.Control, inner.Bit)
+	case *CNotGate:
+		fmt.Fprintf(w, "ccx q[%d],q[%d],q[%d];\n", g.Control, inner.Control, inner.Target)
+	case *SwapGate:
+		fmt.Fprintf(w, "cswap q[%d],q[%d],q[%d];\n", g.Control, inner.A, inner.B)
+	case *RZGate:
+		fmt.Fprintf(w, "crz(%s) q[%d],q[%d];\n", formatQASMFloat(inner.Theta), g.Control,
+			inner.Bit)
+	case *PhaseGate:
+		fmt.Fprintf(w, "cu1(%s) q[%d],q[%d];\n", formatQASMFloat(inner.Phi), g.Control,
+			inner.Bit)
+	case *U3Gate:
+		fmt.Fprintf(w, "cu3(%s,%s,%s) q[%d],q[%d];\n", formatQASMFloat(inner.Theta),
+			formatQASMFloat(inner.Phi), formatQASMFloat(inner.Lambda), g.Control, inner.Bit)
+	default:
+		return writeQASMGates(w, numBits, Record(numBits, g.Apply))
+	}
+	return nil
+}
+
+func containsMeasurement(c Circuit) bool {
+	for _, g := range c {
+		switch g := g.(type) {
+		case *MeasureGate:
+			return true
+		case Circuit:
+			if containsMeasurement(g) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// u3Matrix computes the matrix for a qelib1 u3 gate.
+func u3Matrix(theta, phi, lambda float64) Matrix2 {
+	cos := complex(math.Cos(theta/2), 0)
+	sin := complex(math.Sin(theta/2), 0)
+	return Matrix2{
+		cos,
+		-cmplx.Exp(complex(0, lambda)) * sin,
+		cmplx.Exp(complex(0, phi)) * sin,
+		cmplx.Exp(complex(0, phi+lambda)) * cos,
+	}
+}
+
+// u3Angles finds angles such that u3(theta, phi, lambda)
+// is equal to m up to a global phase.
+func u3Angles(m *Matrix2) (theta, phi, lambda float64) {
+	// u3 is RZ(phi)*RY(theta)*RZ(lambda) up to phase.
+	angles := EulerDecompose(m, EulerZYZ)
+	return angles.Beta, angles.Alpha, angles.Gamma
+}
+
+func formatQASMFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
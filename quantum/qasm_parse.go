@@ -0,0 +1,842 @@
+package quantum
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// qelib1Composite defines the gates from qelib1.inc that
+// have no direct equivalent in this package, in terms of
+// gates that do.
+const qelib1Composite = `
+gate cz a,b { h b; cx a,b; h b; }
+gate cy a,b { sdg b; cx a,b; s b; }
+gate crz(lambda) a,b { u1(lambda/2) b; cx a,b; u1(-lambda/2) b; cx a,b; }
+gate cu1(lambda) a,b { u1(lambda/2) a; cx a,b; u1(-lambda/2) b; cx a,b; u1(lambda/2) b; }
+gate cu3(theta,phi,lambda) c,t {
+  u1((lambda+phi)/2) c;
+  u1((lambda-phi)/2) t;
+  cx c,t;
+  u3(-theta/2,0,-(phi+lambda)/2) t;
+  cx c,t;
+  u3(theta/2,phi,0) t;
+}
+gate u0(gamma) q { U(0,0,0) q; }
+gate u(theta,phi,lambda) q { U(theta,phi,lambda) q; }
+gate p(lambda) q { u1(lambda) q; }
+gate sx a { sdg a; h a; sdg a; }
+gate sxdg a { s a; h a; s a; }
+gate cp(lambda) a,b { cu1(lambda) a,b; }
+gate cu(theta,phi,lambda,gamma) c,t { p(gamma) c; cu3(theta,phi,lambda) c,t; }
+gate crx(lambda) a,b { u1(pi/2) b; cx a,b; u3(-lambda/2,0,0) b; cx a,b; u3(lambda/2,-pi/2,0) b; }
+gate cry(lambda) a,b { ry(lambda/2) b; cx a,b; ry(-lambda/2) b; cx a,b; }
+gate csx a,b { h b; cu1(pi/2) a,b; h b; }
+gate rzz(theta) a,b { cx a,b; rz(theta) b; cx a,b; }
+gate rxx(theta) a,b { h a; h b; rzz(theta) a,b; h a; h b; }
+gate rccx a,b,c {
+  u2(0,pi) c;
+  u1(pi/4) c;
+  cx b,c;
+  u1(-pi/4) c;
+  cx a,c;
+  u1(pi/4) c;
+  cx b,c;
+  u1(-pi/4) c;
+  u2(0,pi) c;
+}
+`
+
+// ParseQASM decodes an OpenQASM 2.0 program into a
+// Circuit, and returns the total number of qubits.
+//
+// Qubits from every qreg are flattened into one index
+// space, in the order the registers are declared.
+// Gates from qelib1.inc are converted to the closest
+// gates in this package, and user-defined gates are
+// expanded inline. Measurements become MeasureGates, and
+// barriers are ignored.
+//
+// Classical control (if statements), reset, and opaque
+// gates are not supported, nor are the multi-controlled
+// gates c3x, c3sqrtx, c4x, and rc3x from qelib1.inc.
+func ParseQASM(r io.Reader) (Circuit, int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	tokens, err := tokenizeQASM(string(data))
+	if err != nil {
+		return nil, 0, err
+	}
+	p := &qasmParser{
+		tokens: tokens,
+		qregs:  map[string]qasmReg{},
+		cregs:  map[string]qasmReg{},
+		gates:  map[string]*qasmGateDef{},
+	}
+	for !p.done() {
+		if err := p.parseStatement(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return p.circuit, p.numBits, nil
+}
+
+type qasmToken struct {
+	Text string
+	Line int
+}
+
+func tokenizeQASM(source string) ([]qasmToken, error) {
+	var tokens []qasmToken
+	line := 1
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '"':
+			i++
+			for i < len(runes) && runes[i] != '"' && runes[i] != '\n' {
+				i++
+			}
+			if i == len(runes) || runes[i] != '"' {
+				return nil, fmt.Errorf("parse QASM: line %d: unterminated string", line)
+			}
+			i++
+			tokens = append(tokens, qasmToken{Text: string(runes[start:i]), Line: line})
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) ||
+				runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, qasmToken{Text: string(runes[start:i]), Line: line})
+		case unicode.IsDigit(r) || r == '.':
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				i++
+				if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+					i++
+				}
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, qasmToken{Text: string(runes[start:i]), Line: line})
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '>',
+			r == '=' && i+1 < len(runes) && runes[i+1] == '=':
+			i += 2
+			tokens = append(tokens, qasmToken{Text: string(runes[start:i]), Line: line})
+		case strings.ContainsRune(";,()[]{}+-*/^", r):
+			i++
+			tokens = append(tokens, qasmToken{Text: string(r), Line: line})
+		default:
+			return nil, fmt.Errorf("parse QASM: line %d: unexpected character %q", line, r)
+		}
+	}
+	return tokens, nil
+}
+
+type qasmReg struct {
+	Offset int
+	Size   int
+}
+
+// A qasmExpr evaluates an expression given the values of
+// gate parameters.
+type qasmExpr func(env map[string]float64) float64
+
+type qasmCall struct {
+	Name   string
+	Params []qasmExpr
+	Args   []string
+	Line   int
+}
+
+type qasmGateDef struct {
+	Params []string
+	Args   []string
+	Body   []*qasmCall
+}
+
+type qasmParser struct {
+	tokens []qasmToken
+	pos    int
+
+	qelib   bool
+	numBits int
+	qregs   map[string]qasmReg
+	cregs   map[string]qasmReg
+	gates   map[string]*qasmGateDef
+	circuit Circuit
+}
+
+func (p *qasmParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *qasmParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos].Text
+}
+
+func (p *qasmParser) line() int {
+	if p.done() {
+		if len(p.tokens) == 0 {
+			return 1
+		}
+		return p.tokens[len(p.tokens)-1].Line
+	}
+	return p.tokens[p.pos].Line
+}
+
+func (p *qasmParser) errorf(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("parse QASM: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *qasmParser) next() (string, error) {
+	if p.done() {
+		return "", p.errorf(p.line(), "unexpected end of input")
+	}
+	p.pos++
+	return p.tokens[p.pos-1].Text, nil
+}
+
+func (p *qasmParser) expect(text string) error {
+	line := p.line()
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+	if tok != text {
+		return p.errorf(line, "expected %q but got %q", text, tok)
+	}
+	return nil
+}
+
+func (p *qasmParser) ident() (string, error) {
+	line := p.line()
+	tok, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if !isQASMIdent(tok) {
+		return "", p.errorf(line, "expected identifier but got %q", tok)
+	}
+	return tok, nil
+}
+
+func (p *qasmParser) integer() (int, error) {
+	line := p.line()
+	tok, err := p.next()
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(tok)
+	if err != nil || n < 0 {
+		return 0, p.errorf(line, "expected non-negative integer but got %q", tok)
+	}
+	return n, nil
+}
+
+func (p *qasmParser) parseStatement() error {
+	line := p.line()
+	tok, _ := p.next()
+	switch tok {
+	case "OPENQASM":
+		version, err := p.next()
+		if err != nil {
+			return err
+		}
+		if version != "2.0" {
+			return p.errorf(line, "unsupported version %s", version)
+		}
+		return p.expect(";")
+	case "include":
+		name, err := p.next()
+		if err != nil {
+			return err
+		}
+		if name != `"qelib1.inc"` {
+			return p.errorf(line, "unsupported include %s", name)
+		}
+		if err := p.expect(";"); err != nil {
+			return err
+		}
+		return p.includeQelib()
+	case "qreg", "creg":
+		return p.parseRegister(tok == "qreg")
+	case "gate":
+		return p.parseGateDef()
+	case "measure":
+		return p.parseMeasure()
+	case "barrier":
+		_, err := p.parseArgs()
+		return err
+	case "opaque", "reset", "if":
+		return p.errorf(line, "unsupported statement %q", tok)
+	default:
+		p.pos--
+		call, err := p.parseCall(nil)
+		if err != nil {
+			return err
+		}
+		return p.applyTopLevel(call)
+	}
+}
+
+func (p *qasmParser) includeQelib() error {
+	if p.qelib {
+		return nil
+	}
+	p.qelib = true
+	tokens, err := tokenizeQASM(qelib1Composite)
+	if err != nil {
+		return err
+	}
+	sub := &qasmParser{tokens: tokens, qelib: true, gates: p.gates}
+	for !sub.done() {
+		if err := sub.parseStatement(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *qasmParser) parseRegister(quantum bool) error {
+	line := p.line()
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("["); err != nil {
+		return err
+	}
+	size, err := p.integer()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("]"); err != nil {
+		return err
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+	if _, ok := p.qregs[name]; ok {
+		return p.errorf(line, "register %s already declared", name)
+	} else if _, ok := p.cregs[name]; ok {
+		return p.errorf(line, "register %s already declared", name)
+	}
+	if quantum {
+		p.qregs[name] = qasmReg{Offset: p.numBits, Size: size}
+		p.numBits += size
+	} else {
+		p.cregs[name] = qasmReg{Size: size}
+	}
+	return nil
+}
+
+func (p *qasmParser) parseGateDef() error {
+	line := p.line()
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, ok := p.gates[name]; ok || isBuiltinQASMGate(name) {
+		return p.errorf(line, "gate %s already defined", name)
+	}
+	def := &qasmGateDef{}
+	if p.peek() == "(" {
+		p.pos++
+		if p.peek() != ")" {
+			if def.Params, err = p.identList(); err != nil {
+				return err
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return err
+		}
+	}
+	if def.Args, err = p.identList(); err != nil {
+		return err
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	params := map[string]bool{}
+	for _, param := range def.Params {
+		params[param] = true
+	}
+	args := map[string]bool{}
+	for _, arg := range def.Args {
+		args[arg] = true
+	}
+	for p.peek() != "}" {
+		if p.peek() == "barrier" {
+			p.pos++
+			if _, err := p.identList(); err != nil {
+				return err
+			}
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+			continue
+		}
+		call, err := p.parseCall(params)
+		if err != nil {
+			return err
+		}
+		// Gates must be defined before they are used, which
+		// also rules out recursive definitions.
+		if !p.isDefinedGate(call.Name) {
+			return p.errorf(call.Line, "unknown gate %s", call.Name)
+		}
+		for _, arg := range call.Args {
+			if !args[arg] {
+				return p.errorf(call.Line, "unknown qubit argument %s", arg)
+			}
+		}
+		def.Body = append(def.Body, call)
+	}
+	p.pos++
+	p.gates[name] = def
+	return nil
+}
+
+func (p *qasmParser) identList() ([]string, error) {
+	var res []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, name)
+		if p.peek() != "," {
+			return res, nil
+		}
+		p.pos++
+	}
+}
+
+// parseCall parses a gate application. If params is
+// non-nil, the call is in a gate definition, so arguments
+// must be plain identifiers and expressions may refer to
+// the given parameters.
+func (p *qasmParser) parseCall(params map[string]bool) (*qasmCall, error) {
+	call := &qasmCall{Line: p.line()}
+	var err error
+	if call.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if p.peek() == "(" {
+		p.pos++
+		for p.peek() != ")" {
+			expr, err := p.parseExpr(params)
+			if err != nil {
+				return nil, err
+			}
+			call.Params = append(call.Params, expr)
+			if p.peek() != "," {
+				break
+			}
+			p.pos++
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	if params == nil {
+		// Top-level arguments may be registers or indexed
+		// qubits, so they are parsed by the caller.
+		return call, nil
+	}
+	if call.Args, err = p.identList(); err != nil {
+		return nil, err
+	}
+	return call, p.expect(";")
+}
+
+// parseArgs parses a list of qubit arguments followed by
+// a semicolon, where each argument is either a register
+// or an indexed qubit.
+func (p *qasmParser) parseArgs() ([][]int, error) {
+	var res [][]int
+	for {
+		arg, err := p.parseArg(p.qregs)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, arg)
+		if p.peek() != "," {
+			break
+		}
+		p.pos++
+	}
+	return res, p.expect(";")
+}
+
+func (p *qasmParser) parseArg(regs map[string]qasmReg) ([]int, error) {
+	line := p.line()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	reg, ok := regs[name]
+	if !ok {
+		return nil, p.errorf(line, "unknown register %s", name)
+	}
+	if p.peek() != "[" {
+		var res []int
+		for i := 0; i < reg.Size; i++ {
+			res = append(res, reg.Offset+i)
+		}
+		return res, nil
+	}
+	p.pos++
+	idx, err := p.integer()
+	if err != nil {
+		return nil, err
+	}
+	if idx >= reg.Size {
+		return nil, p.errorf(line, "index %d out of range for register %s", idx, name)
+	}
+	return []int{reg.Offset + idx}, p.expect("]")
+}
+
+func (p *qasmParser) parseMeasure() error {
+	line := p.line()
+	qubits, err := p.parseArg(p.qregs)
+	if err != nil {
+		return err
+	}
+	if err := p.expect("->"); err != nil {
+		return err
+	}
+	bits, err := p.parseArg(p.cregs)
+	if err != nil {
+		return err
+	}
+	if len(bits) != len(qubits) {
+		return p.errorf(line, "mismatched register sizes in measurement")
+	}
+	for _, q := range qubits {
+		p.circuit = append(p.circuit, &MeasureGate{Bit: q})
+	}
+	return p.expect(";")
+}
+
+func (p *qasmParser) applyTopLevel(call *qasmCall) error {
+	args, err := p.parseArgs()
+	if err != nil {
+		return err
+	}
+	size := 1
+	for _, arg := range args {
+		if len(arg) > 1 {
+			if size > 1 && len(arg) != size {
+				return p.errorf(call.Line, "mismatched register sizes")
+			}
+			size = len(arg)
+		}
+	}
+	params := make([]float64, len(call.Params))
+	for i, param := range call.Params {
+		params[i] = param(nil)
+	}
+	for i := 0; i < size; i++ {
+		qubits := make([]int, len(args))
+		for j, arg := range args {
+			if len(arg) == 1 {
+				qubits[j] = arg[0]
+			} else {
+				qubits[j] = arg[i]
+			}
+		}
+		if err := p.applyGate(call.Name, params, qubits, call.Line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *qasmParser) applyGate(name string, params []float64, qubits []int, line int) error {
+	for i, q := range qubits {
+		for _, q1 := range qubits[:i] {
+			if q == q1 {
+				return p.errorf(line, "repeated qubit argument to %s", name)
+			}
+		}
+	}
+	if def, ok := p.gates[name]; ok {
+		if len(params) != len(def.Params) || len(qubits) != len(def.Args) {
+			return p.errorf(line, "wrong number of arguments to %s", name)
+		}
+		env := map[string]float64{}
+		for i, param := range def.Params {
+			env[param] = params[i]
+		}
+		argMap := map[string]int{}
+		for i, arg := range def.Args {
+			argMap[arg] = qubits[i]
+		}
+		for _, call := range def.Body {
+			subParams := make([]float64, len(call.Params))
+			for i, param := range call.Params {
+				subParams[i] = param(env)
+			}
+			subQubits := make([]int, len(call.Args))
+			for i, arg := range call.Args {
+				subQubits[i] = argMap[arg]
+			}
+			if err := p.applyGate(call.Name, subParams, subQubits, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !p.isDefinedGate(name) {
+		return p.errorf(line, "unknown gate %s", name)
+	}
+	numParams, numQubits, _ := builtinQASMGate(name)
+	if len(params) != numParams || len(qubits) != numQubits {
+		return p.errorf(line, "wrong number of arguments to %s", name)
+	}
+	var gate Gate
+	switch name {
+	case "h":
+		gate = &HGate{Bit: qubits[0]}
+	case "x":
+		gate = &XGate{Bit: qubits[0]}
+	case "y":
+		gate = &YGate{Bit: qubits[0]}
+	case "z":
+		gate = &ZGate{Bit: qubits[0]}
+	case "s", "sdg":
+		gate = &SGate{Bit: qubits[0], Conjugate: name == "sdg"}
+	case "t", "tdg":
+		gate = &TGate{Bit: qubits[0], Conjugate: name == "tdg"}
+	case "id":
+		return nil
+	case "U", "u3":
+		gate = qasmUnitary(&U3Gate{Bit: qubits[0], Theta: params[0], Phi: params[1],
+			Lambda: params[2]})
+	case "u2":
+		gate = qasmUnitary(&U3Gate{Bit: qubits[0], Theta: math.Pi / 2, Phi: params[0],
+			Lambda: params[1]})
+	case "u1":
+		gate = qasmUnitary(&PhaseGate{Bit: qubits[0], Phi: params[0]})
+	case "rx":
+		gate = &RXGate{Bit: qubits[0], Theta: params[0]}
+	case "ry":
+		gate = &RYGate{Bit: qubits[0], Theta: params[0]}
+	case "rz":
+		gate = &RZGate{Bit: qubits[0], Theta: params[0]}
+	case "CX", "cx":
+		gate = &CNotGate{Control: qubits[0], Target: qubits[1]}
+	case "ch":
+		gate = &CHGate{Control: qubits[0], Target: qubits[1]}
+	case "swap":
+		gate = &SwapGate{A: qubits[0], B: qubits[1]}
+	case "ccx":
+		gate = &CCNotGate{Control1: qubits[0], Control2: qubits[1], Target: qubits[2]}
+	case "cswap":
+		gate = &CSwapGate{Control: qubits[0], A: qubits[1], B: qubits[2]}
+	}
+	p.circuit = append(p.circuit, gate)
+	return nil
+}
+
+// qasmUnitary simplifies a u1 or u3 gate to a primitive
+// gate when one applies the same matrix.
+func qasmUnitary(g Gate) Gate {
+	var bit int
+	var m Matrix2
+	switch g := g.(type) {
+	case *PhaseGate:
+		bit, m = g.Bit, g.Matrix()
+	case *U3Gate:
+		bit, m = g.Bit, g.Matrix()
+	}
+	if classifyMatrix(&m) != "" {
+		return unitaryToGate(bit, &m)
+	}
+	return g
+}
+
+// builtinQASMGate gets the number of parameters and
+// qubits for gates that are converted directly.
+func builtinQASMGate(name string) (numParams, numQubits int, ok bool) {
+	switch name {
+	case "h", "x", "y", "z", "s", "sdg", "t", "tdg", "id":
+		return 0, 1, true
+	case "U", "u3":
+		return 3, 1, true
+	case "u2":
+		return 2, 1, true
+	case "u1", "rx", "ry", "rz":
+		return 1, 1, true
+	case "CX", "cx", "ch", "swap":
+		return 0, 2, true
+	case "ccx", "cswap":
+		return 0, 3, true
+	}
+	return 0, 0, false
+}
+
+// isDefinedGate checks if a gate may be applied, either
+// because it was defined earlier or because it is built
+// in. Most built-in gates require qelib1.inc.
+func (p *qasmParser) isDefinedGate(name string) bool {
+	if _, ok := p.gates[name]; ok {
+		return true
+	}
+	return isBuiltinQASMGate(name) && (p.qelib || name == "U" || name == "CX")
+}
+
+func isBuiltinQASMGate(name string) bool {
+	_, _, ok := builtinQASMGate(name)
+	return ok
+}
+
+func isQASMIdent(tok string) bool {
+	for i, r := range tok {
+		if !(unicode.IsLetter(r) || r == '_' || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return tok != ""
+}
+
+// parseExpr parses an arithmetic expression. If params is
+// non-nil, identifiers in it may be used as variables.
+func (p *qasmParser) parseExpr(params map[string]bool) (qasmExpr, error) {
+	left, err := p.parseTerm(params)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "+" || p.peek() == "-" {
+		op, _ := p.next()
+		right, err := p.parseTerm(params)
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "+" {
+			left = func(env map[string]float64) float64 { return l(env) + right(env) }
+		} else {
+			left = func(env map[string]float64) float64 { return l(env) - right(env) }
+		}
+	}
+	return left, nil
+}
+
+func (p *qasmParser) parseTerm(params map[string]bool) (qasmExpr, error) {
+	left, err := p.parseUnary(params)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "*" || p.peek() == "/" {
+		op, _ := p.next()
+		right, err := p.parseUnary(params)
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "*" {
+			left = func(env map[string]float64) float64 { return l(env) * right(env) }
+		} else {
+			left = func(env map[string]float64) float64 { return l(env) / right(env) }
+		}
+	}
+	return left, nil
+}
+
+func (p *qasmParser) parseUnary(params map[string]bool) (qasmExpr, error) {
+	if p.peek() == "-" {
+		p.pos++
+		inner, err := p.parseUnary(params)
+		if err != nil {
+			return nil, err
+		}
+		return func(env map[string]float64) float64 { return -inner(env) }, nil
+	}
+	base, err := p.parsePrimary(params)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == "^" {
+		p.pos++
+		exp, err := p.parseUnary(params)
+		if err != nil {
+			return nil, err
+		}
+		return func(env map[string]float64) float64 {
+			return math.Pow(base(env), exp(env))
+		}, nil
+	}
+	return base, nil
+}
+
+func (p *qasmParser) parsePrimary(params map[string]bool) (qasmExpr, error) {
+	line := p.line()
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok == "(" {
+		inner, err := p.parseExpr(params)
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	} else if tok == "pi" {
+		return func(env map[string]float64) float64 { return math.Pi }, nil
+	} else if fn, ok := qasmFunctions[tok]; ok {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseExpr(params)
+		if err != nil {
+			return nil, err
+		}
+		return func(env map[string]float64) float64 { return fn(inner(env)) }, p.expect(")")
+	} else if isQASMIdent(tok) {
+		if !params[tok] {
+			return nil, p.errorf(line, "unknown parameter %s", tok)
+		}
+		return func(env map[string]float64) float64 { return env[tok] }, nil
+	}
+	value, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		return nil, p.errorf(line, "unexpected %q in expression", tok)
+	}
+	return func(env map[string]float64) float64 { return value }, nil
+}
+
+var qasmFunctions = map[string]func(float64) float64{
+	"sin":  math.Sin,
+	"cos":  math.Cos,
+	"tan":  math.Tan,
+	"exp":  math.Exp,
+	"ln":   math.Log,
+	"sqrt": math.Sqrt,
+}
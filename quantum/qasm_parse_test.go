@@ -0,0 +1,204 @@
+package quantum
+
+import (
+	"bytes"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseQASM(t *testing.T) {
+	source := `OPENQASM 2.0;
+include "qelib1.inc";
+
+// Registers are flattened in order.
+qreg a[2];
+qreg b[3];
+creg c[2];
+
+gate majority x,y,z {
+  cx z,y;
+  cx z,x;
+  ccx x,y,z;
+}
+gate rot(theta) x { u3(theta/2, -pi/4, 2*pi^2) x; rz(-theta) x; }
+
+h a;
+majority a[0],a[1],b[2];
+rot(0.3) b[0];
+cz a[1],b[1];
+cu3(0.1,0.2,0.3) b[0],b[1];
+crz(1e-1) b[1],a[0];
+cx a,b[0];
+barrier a,b;
+sdg b[2];
+measure a -> c;
+`
+	circuit, numBits, err := ParseQASM(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if numBits != 5 {
+		t.Fatalf("unexpected number of bits: %d", numBits)
+	}
+	if _, ok := circuit[0].(*HGate); !ok {
+		t.Fatalf("unexpected first gate: %v", circuit[0])
+	}
+	if m, ok := circuit[len(circuit)-1].(*MeasureGate); !ok || m.Bit != 1 {
+		t.Fatalf("unexpected last gate: %v", circuit[len(circuit)-1])
+	}
+
+	expected := Circuit{
+		&HGate{Bit: 0},
+		&HGate{Bit: 1},
+		&CNotGate{Control: 4, Target: 1},
+		&CNotGate{Control: 4, Target: 0},
+		&CCNotGate{Control1: 0, Control2: 1, Target: 4},
+		&UnitaryGate{Bit: 2, Matrix: u3Matrix(0.15, -0.7853981633974483, 2*9.869604401089358)},
+		&UnitaryGate{Bit: 2, Matrix: u3Matrix(0, 0, -0.3)},
+		&HGate{Bit: 3},
+		&CNotGate{Control: 1, Target: 3},
+		&HGate{Bit: 3},
+		testFnGate(func(c Computer) {
+			m := u3Matrix(0.1, 0.2, 0.3)
+			CUnitary(c, 2, 3, &m)
+		}),
+		testFnGate(func(c Computer) {
+			m := Matrix2{cmplx.Exp(-0.05i), 0, 0, cmplx.Exp(0.05i)}
+			CUnitary(c, 3, 0, &m)
+		}),
+		&CNotGate{Control: 0, Target: 2},
+		&CNotGate{Control: 1, Target: 2},
+		&SGate{Bit: 4, Conjugate: true},
+	}
+	actual := circuit[:len(circuit)-2]
+	if !ExtractUnitary(5, actual).ApproxEqualPhase(ExtractUnitary(5, expected), 1e-8) {
+		t.Fatal("incorrect unitary")
+	}
+}
+
+func testFnGate(f func(c Computer)) Gate {
+	return &FnGate{Forward: f, Str: "Fn"}
+}
+
+func TestParseQASMRoundTrip(t *testing.T) {
+	circuit := Circuit{
+		&HGate{Bit: 0},
+		&TGate{Bit: 1, Conjugate: true},
+		&SqrtNotGate{Bit: 2},
+		&CSqrtNotGate{Control: 2, Target: 0, Invert: true},
+		&SqrtTGate{Bit: 1},
+		&CSwapGate{Control: 1, A: 0, B: 2},
+		&UnitaryGate{Bit: 2, Matrix: RandomMatrix2()},
+	}
+	for i := 0; i < 3; i++ {
+		circuit = append(circuit, randomizedCircuit(rand.Perm(3), rand.Int(), 10)...)
+	}
+	var buf bytes.Buffer
+	circuit = Record(3, circuit.Apply)
+	if err := WriteQASM(&buf, 3, circuit); err != nil {
+		t.Fatal(err)
+	}
+	parsed, numBits, err := ParseQASM(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if numBits != 3 {
+		t.Fatalf("unexpected number of bits: %d", numBits)
+	}
+	if !ExtractUnitary(3, parsed).ApproxEqualPhase(ExtractUnitary(3, circuit), 1e-8) {
+		t.Fatal("incorrect unitary")
+	}
+}
+
+func TestParseQASMQelib(t *testing.T) {
+	sx := Matrix2{(1 + 1i) / 2, (1 - 1i) / 2, (1 - 1i) / 2, (1 + 1i) / 2}
+	sxdg := sx
+	sxdg.ConjTranspose()
+
+	// exp(-i*theta/2*Z*Z) applies RZ(theta) or RZ(-theta)
+	// to one qubit depending on the other.
+	rzz := Circuit{
+		&RZGate{Bit: 1, Theta: 0.4},
+		&ControlledGate{Control: 0, Gate: &RZGate{Bit: 1, Theta: -0.8}},
+	}
+	tests := map[string]Gate{
+		"u0(0.5) q[0];":        Circuit{},
+		"u(0.1,0.2,0.3) q[1];": &UnitaryGate{Bit: 1, Matrix: u3Matrix(0.1, 0.2, 0.3)},
+		"p(0.3) q[0];":         &PhaseGate{Bit: 0, Phi: 0.3},
+		"sx q[0];":             &UnitaryGate{Bit: 0, Matrix: sx},
+		"sxdg q[1];":           &UnitaryGate{Bit: 1, Matrix: sxdg},
+		"cp(0.3) q[1],q[0];":   &ControlledGate{Control: 1, Gate: &PhaseGate{Bit: 0, Phi: 0.3}},
+		"crx(0.7) q[0],q[1];":  &ControlledGate{Control: 0, Gate: &RXGate{Bit: 1, Theta: 0.7}},
+		"cry(0.7) q[1],q[0];":  &ControlledGate{Control: 1, Gate: &RYGate{Bit: 0, Theta: 0.7}},
+		"csx q[0],q[1];":       &ControlledGate{Control: 0, Gate: &UnitaryGate{Bit: 1, Matrix: sx}},
+		"rzz(0.4) q[0],q[1];":  rzz,
+		"rxx(0.4) q[0],q[1];":  Circuit{&HGate{Bit: 0}, &HGate{Bit: 1}, rzz, &HGate{Bit: 0}, &HGate{Bit: 1}},
+		"cu(0.1,0.2,0.3,0.4) q[0],q[1];": Circuit{
+			&PhaseGate{Bit: 0, Phi: 0.4},
+			&ControlledGate{Control: 0, Gate: &U3Gate{Bit: 1, Theta: 0.1, Phi: 0.2, Lambda: 0.3}},
+		},
+	}
+	for statement, expected := range tests {
+		source := "include \"qelib1.inc\";\nqreg q[2];\n" + statement
+		circuit, _, err := ParseQASM(strings.NewReader(source))
+		if err != nil {
+			t.Errorf("%s: %s", statement, err)
+			continue
+		}
+		actual := ExtractUnitary(2, circuit)
+		expectedMat := ExtractUnitary(2, expected)
+		if strings.HasPrefix(statement, "c") {
+			// Controlled gates must preserve relative phase.
+			if !actual.ApproxEqual(expectedMat, 1e-8) {
+				t.Errorf("%s: incorrect unitary", statement)
+			}
+		} else if !actual.ApproxEqualPhase(expectedMat, 1e-8) {
+			t.Errorf("%s: incorrect unitary", statement)
+		}
+	}
+
+	// rccx is a Toffoli gate up to relative phases.
+	source := "include \"qelib1.inc\";\nqreg q[3];\nrccx q[0],q[1],q[2];"
+	circuit, _, err := ParseQASM(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := ExtractUnitary(3, circuit)
+	expected := ExtractUnitary(3, &CCNotGate{Control1: 0, Control2: 1, Target: 2})
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			if math.Abs(cmplx.Abs(actual.At(i, j))-cmplx.Abs(expected.At(i, j))) > 1e-8 {
+				t.Fatal("incorrect rccx unitary")
+			}
+		}
+	}
+}
+
+func TestParseQASMErrors(t *testing.T) {
+	sources := map[string]string{
+		"line 3: unknown gate foo":      "OPENQASM 2.0;\nqreg q[1];\nfoo q[0];",
+		"line 2: unknown gate h":        "qreg q[1];\nh q[0];",
+		"line 4: index 3 out of range":  "include \"qelib1.inc\";\nqreg q[2];\n\nh q[3];",
+		"line 2: unknown parameter y":   "include \"qelib1.inc\";\ngate g(x) a { rz(y) a; }",
+		"line 3: wrong number":          "include \"qelib1.inc\";\nqreg q[2];\ncx q[0];",
+		"line 1: unsupported statement": "reset q[0];",
+		"line 3: mismatched register":   "qreg a[2];\nqreg b[3];\nCX a,b;",
+		"line 2: unexpected end":        "qreg a[2];\nU(0,0,0) a",
+		"line 1: unknown gate foo":      "gate foo a { foo a; }\nqreg q[1];\nfoo q[0];",
+		"line 2: unknown gate bar":      "gate foo a { U(0,0,0) a; }\ngate baz a { bar a; }\ngate bar a { foo a; }",
+		"line 1: unknown gate h":        "gate foo a { h a; }",
+		"line 3: unknown gate c3x":      "include \"qelib1.inc\";\nqreg q[4];\nc3x q[0],q[1],q[2],q[3];",
+		"line 3: unknown gate c4x":      "include \"qelib1.inc\";\nqreg q[5];\nc4x q[0],q[1],q[2],q[3],q[4];",
+	}
+	for expected, source := range sources {
+		_, _, err := ParseQASM(strings.NewReader(source))
+		if err == nil {
+			t.Errorf("expected error for %q", source)
+		} else if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error %q but got %q", expected, err.Error())
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package quantum
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func ExampleWriteQASM() {
+	WriteQASM(os.Stdout, 3, Circuit{
+		&HGate{Bit: 0},
+		&CNotGate{Control: 0, Target: 1},
+		&TGate{Bit: 2, Conjugate: true},
+		&SqrtTGate{Bit: 1},
+		&CCNotGate{Control1: 0, Control2: 1, Target: 2},
+		&UnitaryGate{Bit: 1, Matrix: Matrix2{0, 1i, 1i, 0}},
+	})
+
+	// Output:
+	// OPENQASM 2.0;
+	// include "qelib1.inc";
+	// qreg q[3];
+	// h q[0];
+	// cx q[0],q[1];
+	// tdg q[2];
+	// u1(pi/8) q[1];
+	// ccx q[0],q[1],q[2];
+	// u3(3.141592653589793,3.141592653589793,0) q[1];
+}
+
+func TestWriteQASMErrors(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteQASM(&buf, 2, Circuit{
+		&HGate{Bit: 0},
+		&FnGate{Forward: func(c Computer) {}, Backward: func(c Computer) {}, Str: "Fn"},
+	})
+	if err == nil {
+		t.Error("expected error for FnGate")
+	}
+	if buf.Len() != 0 {
+		t.Error("unexpected output on error")
+	}
+	err = WriteQASM(&buf, 2, Circuit{NewClassicalGate(func(b []bool) []bool { return b }, "")})
+	if err == nil {
+		t.Error("expected error for ClassicalGate")
+	}
+}
+
+func TestU3Angles(t *testing.T) {
+	mats := []Matrix2{
+		{1, 0, 0, 1},
+		{0, 1, 1, 0},
+		{1, 0, 0, 1i},
+		{0, 1i, -1, 0},
+	}
+	for i := 0; i < 100; i++ {
+		mats = append(mats, RandomMatrix2())
+	}
+	for _, m := range mats {
+		theta, phi, lambda := u3Angles(&m)
+		actual := u3Matrix(theta, phi, lambda)
+		if m.PhaseDistance(&actual) > 1e-8 {
+			t.Errorf("incorrect angles for %v: %f, %f, %f", m, theta, phi, lambda)
+		}
+	}
+}
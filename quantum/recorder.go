@@ -0,0 +1,88 @@
+package quantum
+
+// A Recorder is a Computer that records the operations
+// applied to it as a Circuit of primitive gates, rather
+// than simulating them.
+//
+// Known single-qubit matrices are recorded as the
+// corresponding gates (e.g. HGate or TGate), and other
+// matrices are recorded as a UnitaryGate.
+type Recorder struct {
+	numBits int
+	Circuit Circuit
+}
+
+// NewRecorder creates a Recorder with no gates.
+func NewRecorder(numBits int) *Recorder {
+	return &Recorder{numBits: numBits}
+}
+
+// Record traces a function into a Circuit.
+//
+// The function f must not perform measurements.
+func Record(numBits int, f func(c Computer)) Circuit {
+	r := NewRecorder(numBits)
+	f(r)
+	return r.Circuit
+}
+
+func (r *Recorder) NumBits() int {
+	return r.numBits
+}
+
+func (r *Recorder) InUse(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= r.numBits {
+		panic("bit index out of range")
+	}
+	return false
+}
+
+func (r *Recorder) Measure(bitIdx int) bool {
+	panic("measurement cannot be recorded")
+}
+
+func (r *Recorder) Unitary(target int, m *Matrix2) {
+	if target < 0 || target >= r.numBits {
+		panic("bit index out of range")
+	}
+	r.Circuit = append(r.Circuit, unitaryToGate(target, m))
+}
+
+func (r *Recorder) CNot(control, target int) {
+	if control < 0 || control >= r.numBits || target < 0 || target >= r.numBits {
+		panic("bit index out of range")
+	}
+	if control == target {
+		panic("overlapping control and target is not invertible")
+	}
+	r.Circuit = append(r.Circuit, &CNotGate{Control: control, Target: target})
+}
+
+// unitaryToGate creates the simplest Gate that applies a
+// single-qubit matrix.
+func unitaryToGate(target int, m *Matrix2) Gate {
+	switch classifyMatrix(m) {
+	case "H":
+		return &HGate{Bit: target}
+	case "T":
+		return &TGate{Bit: target}
+	case "T*":
+		return &TGate{Bit: target, Conjugate: true}
+	case "S":
+		return &SGate{Bit: target}
+	case "S*":
+		return &SGate{Bit: target, Conjugate: true}
+	case "X":
+		return &XGate{Bit: target}
+	case "Y":
+		return &YGate{Bit: target}
+	case "Z":
+		return &ZGate{Bit: target}
+	case "SqrtT":
+		return &SqrtTGate{Bit: target}
+	case "SqrtT*":
+		return &SqrtTGate{Bit: target, Conjugate: true}
+	default:
+		return &UnitaryGate{Bit: target, Matrix: *m}
+	}
+}
@@ -0,0 +1,51 @@
+package quantum
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleRecord() {
+	fmt.Println(Record(2, func(c Computer) {
+		CH(c, 0, 1)
+	}))
+
+	// Output:
+	// T(1) T(1) H(1) T(1) CNot(0, 1) T*(1) H(1) T*(1) T*(1)
+}
+
+func TestRecord(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		m := RandomMatrix2()
+		f := func(c Computer) {
+			CSwap(c, 0, 1, 2)
+			ModAdd(c, Reg{0, 1}, Reg{2, 3}, Reg{4, 5}, 6)
+			SqrtT(c, 3)
+			Y(c, 1)
+			c.Unitary(2, &m)
+		}
+		circuit := Record(7, f)
+		if _, ok := circuit[len(circuit)-1].(*UnitaryGate); !ok {
+			t.Fatal("expected a UnitaryGate for a random matrix")
+		}
+		s := RandomSimulation(7)
+		s1 := s.Copy()
+		s2 := s.Copy()
+		f(s1)
+		circuit.Apply(s2)
+		if !s1.ApproxEqual(s2, 1e-8) {
+			t.Fatal("incorrect result")
+		}
+		circuit.Inverse().Apply(s2)
+		if !s2.ApproxEqual(s, 1e-8) {
+			t.Fatal("incorrect inverse")
+		}
+	}
+}
+
+func TestUnitaryGateString(t *testing.T) {
+	g := &UnitaryGate{Bit: 3, Matrix: Matrix2{1, 0, 0, 1i}}
+	if s := g.String(); s != "U(3, (1+0i), (0+0i), (0+0i), (0+1i))" {
+		t.Errorf("unexpected string: %s", s)
+	}
+}
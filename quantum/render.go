@@ -6,6 +6,8 @@ import (
 	"image/color"
 	"image/draw"
 	"math"
+	"strconv"
+	"strings"
 
 	"github.com/fogleman/gg"
 	"github.com/golang/freetype/truetype"
@@ -122,6 +124,14 @@ func (t *TGate) Render(params *RenderParams) (*image.RGBA, error) {
 	}
 }
 
+func (s *SGate) Render(params *RenderParams) (*image.RGBA, error) {
+	if s.Conjugate {
+		return RenderText(params, s.Bit, "S*")
+	} else {
+		return RenderText(params, s.Bit, "S")
+	}
+}
+
 func (x *XGate) Render(params *RenderParams) (*image.RGBA, error) {
 	return RenderText(params, x.Bit, "X")
 }
@@ -134,6 +144,94 @@ func (z *ZGate) Render(params *RenderParams) (*image.RGBA, error) {
 	return RenderText(params, z.Bit, "Z")
 }
 
+func (u *UnitaryGate) Render(params *RenderParams) (*image.RGBA, error) {
+	return RenderText(params, u.Bit, "U")
+}
+
+func (r *RXGate) Render(params *RenderParams) (*image.RGBA, error) {
+	return RenderText(params, r.Bit, "RX("+formatRenderAngle(r.Theta)+")")
+}
+
+func (r *RYGate) Render(params *RenderParams) (*image.RGBA, error) {
+	return RenderText(params, r.Bit, "RY("+formatRenderAngle(r.Theta)+")")
+}
+
+func (r *RZGate) Render(params *RenderParams) (*image.RGBA, error) {
+	return RenderText(params, r.Bit, "RZ("+formatRenderAngle(r.Theta)+")")
+}
+
+func (p *PhaseGate) Render(params *RenderParams) (*image.RGBA, error) {
+	return RenderText(params, p.Bit, "P("+formatRenderAngle(p.Phi)+")")
+}
+
+func (u *U3Gate) Render(params *RenderParams) (*image.RGBA, error) {
+	return RenderText(params, u.Bit, "U3("+formatRenderAngle(u.Theta)+","+
+		formatRenderAngle(u.Phi)+","+formatRenderAngle(u.Lambda)+")")
+}
+
+func (p *ParamGate) Render(params *RenderParams) (*image.RGBA, error) {
+	parts := make([]string, len(p.Angles))
+	for i, angle := range p.Angles {
+		parts[i] = angle.String()
+	}
+	label := p.Kind
+	if label == "Phase" {
+		label = "P"
+	}
+	return RenderText(params, p.Bit, label+"("+strings.Join(parts, ",")+")")
+}
+
+func (c *ControlledGate) Render(params *RenderParams) (*image.RGBA, error) {
+	renderer, ok := c.Gate.(Renderer)
+	if !ok {
+		return nil, fmt.Errorf("cannot render %T", c.Gate)
+	}
+	img, err := renderer.Render(params)
+	if err != nil {
+		return nil, err
+	}
+	ctx := gg.NewContextForRGBA(img)
+	ctx.SetRGB(0, 0, 0)
+	x := img.Bounds().Dx() / 2
+	controlY := params.QubitY(c.Control)
+	if targetY, ok := nearestDrawnY(params, img, x, controlY); ok {
+		ctx.MoveTo(float64(x), float64(controlY))
+		ctx.LineTo(float64(x), float64(targetY))
+		ctx.Stroke()
+	}
+	ctx.DrawCircle(float64(x), float64(controlY), float64(params.DotSize))
+	ctx.Fill()
+	return img, nil
+}
+
+// nearestDrawnY finds the closest row to y where a column
+// of the image has been drawn on, ignoring the qubit
+// lines. This is used to connect a control wire to the
+// edge of the gate it controls.
+func nearestDrawnY(params *RenderParams, img *image.RGBA, x, y int) (int, bool) {
+	isQubitLine := func(y int) bool {
+		offset := y - params.LineSpace/2
+		offset = ((offset % params.LineSpace) + params.LineSpace) % params.LineSpace
+		return offset <= 1 || offset >= params.LineSpace-1
+	}
+	height := img.Bounds().Dy()
+	for d := 1; d < height; d++ {
+		for _, y1 := range []int{y - d, y + d} {
+			if y1 < 0 || y1 >= height || isQubitLine(y1) {
+				continue
+			}
+			if img.RGBAAt(x, y1).A != 0 {
+				return y1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func formatRenderAngle(theta float64) string {
+	return strconv.FormatFloat(theta, 'g', 3, 64)
+}
+
 func (c Circuit) Render(params *RenderParams) (*image.RGBA, error) {
 	var images []*image.RGBA
 	var totalWidth int
@@ -0,0 +1,210 @@
+package quantum
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"strings"
+)
+
+// knownMatrices maps the names of common single-qubit
+// gates to the matrices that primitives.go applies for
+// them.
+var knownMatrices = []struct {
+	Name   string
+	Matrix Matrix2
+}{
+	{"H", Matrix2{complex(1/math.Sqrt2, 0), complex(1/math.Sqrt2, 0),
+		complex(1/math.Sqrt2, 0), complex(-1/math.Sqrt2, 0)}},
+	{"T", Matrix2{1, 0, 0, tGateValue}},
+	{"T*", Matrix2{1, 0, 0, invTGateValue}},
+	{"S", Matrix2{1, 0, 0, 1i}},
+	{"S*", Matrix2{1, 0, 0, -1i}},
+	{"X", Matrix2{0, 1, 1, 0}},
+	{"Y", Matrix2{0, complex(0, -1), complex(0, 1), 0}},
+	{"Z", Matrix2{1, 0, 0, -1}},
+	{"SqrtT", Matrix2{1, 0, 0, cmplx.Exp(complex(0, math.Pi/8))}},
+	{"SqrtT*", Matrix2{1, 0, 0, cmplx.Exp(complex(0, -math.Pi/8))}},
+}
+
+// classifyMatrix finds the name of a known gate, or
+// returns "" if the matrix is not recognized.
+func classifyMatrix(m *Matrix2) string {
+	for _, known := range knownMatrices {
+		k := known.Matrix
+		if cmplx.Abs(k.M11-m.M11) < epsilon && cmplx.Abs(k.M12-m.M12) < epsilon &&
+			cmplx.Abs(k.M21-m.M21) < epsilon && cmplx.Abs(k.M22-m.M22) < epsilon {
+			return known.Name
+		}
+	}
+	return ""
+}
+
+// A ResourceCounter is a Computer that performs no
+// simulation, but counts the gates applied to it.
+//
+// Since no state is stored, it can be used to estimate
+// the cost of circuits on any number of qubits.
+// Measurements always return false.
+type ResourceCounter struct {
+	numBits int
+
+	// Unitaries counts single-qubit gates by name (e.g.
+	// "H", "T", or "T*"). Unrecognized matrices are
+	// counted under "U".
+	Unitaries map[string]int
+
+	CNots        int
+	Measurements int
+
+	// BitUsage counts the operations involving each
+	// qubit.
+	BitUsage []int
+
+	depths  []int
+	tDepths []int
+}
+
+// NewResourceCounter creates a ResourceCounter for a
+// computer with the given number of qubits.
+func NewResourceCounter(numBits int) *ResourceCounter {
+	return &ResourceCounter{
+		numBits:   numBits,
+		Unitaries: map[string]int{},
+		BitUsage:  make([]int, numBits),
+		depths:    make([]int, numBits),
+		tDepths:   make([]int, numBits),
+	}
+}
+
+func (r *ResourceCounter) NumBits() int {
+	return r.numBits
+}
+
+func (r *ResourceCounter) InUse(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= r.numBits {
+		panic("bit index out of range")
+	}
+	return false
+}
+
+func (r *ResourceCounter) Measure(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= r.numBits {
+		panic("bit index out of range")
+	}
+	r.Measurements++
+	r.BitUsage[bitIdx]++
+	r.depths[bitIdx]++
+	return false
+}
+
+func (r *ResourceCounter) Unitary(target int, m *Matrix2) {
+	if target < 0 || target >= r.numBits {
+		panic("bit index out of range")
+	}
+	name := classifyMatrix(m)
+	if name == "" {
+		name = "U"
+	}
+	r.Unitaries[name]++
+	r.BitUsage[target]++
+	r.depths[target]++
+	if name == "T" || name == "T*" {
+		r.tDepths[target]++
+	}
+}
+
+func (r *ResourceCounter) CNot(control, target int) {
+	if control < 0 || control >= r.numBits || target < 0 || target >= r.numBits {
+		panic("bit index out of range")
+	}
+	if control == target {
+		panic("overlapping control and target is not invertible")
+	}
+	r.CNots++
+	r.BitUsage[control]++
+	r.BitUsage[target]++
+	for _, depths := range [][]int{r.depths, r.tDepths} {
+		d := depths[control]
+		if depths[target] > d {
+			d = depths[target]
+		}
+		depths[control] = d
+		depths[target] = d
+	}
+	r.depths[control]++
+	r.depths[target]++
+}
+
+// Gates counts the total number of gates, including
+// CNots but not measurements.
+func (r *ResourceCounter) Gates() int {
+	res := r.CNots
+	for _, count := range r.Unitaries {
+		res += count
+	}
+	return res
+}
+
+// TCount counts the T and T* gates.
+func (r *ResourceCounter) TCount() int {
+	return r.Unitaries["T"] + r.Unitaries["T*"]
+}
+
+// Depth computes the number of layers of gates, where
+// gates in a layer act on disjoint qubits.
+func (r *ResourceCounter) Depth() int {
+	return maxInt(r.depths)
+}
+
+// TDepth computes the number of layers containing T or
+// T* gates.
+func (r *ResourceCounter) TDepth() int {
+	return maxInt(r.tDepths)
+}
+
+// UsedBits counts the qubits that have been touched.
+func (r *ResourceCounter) UsedBits() int {
+	var res int
+	for _, count := range r.BitUsage {
+		if count > 0 {
+			res++
+		}
+	}
+	return res
+}
+
+// String produces a human-readable cost report.
+func (r *ResourceCounter) String() string {
+	var names []string
+	for name := range r.Unitaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	counts := []string{fmt.Sprintf("CNOT: %d", r.CNots)}
+	for _, name := range names {
+		counts = append(counts, fmt.Sprintf("%s: %d", name, r.Unitaries[name]))
+	}
+	lines := []string{
+		fmt.Sprintf("qubits: %d (%d used)", r.numBits, r.UsedBits()),
+		fmt.Sprintf("gates: %d (%s)", r.Gates(), strings.Join(counts, ", ")),
+		fmt.Sprintf("T-count: %d", r.TCount()),
+		fmt.Sprintf("depth: %d", r.Depth()),
+		fmt.Sprintf("T-depth: %d", r.TDepth()),
+	}
+	if r.Measurements > 0 {
+		lines = append(lines, fmt.Sprintf("measurements: %d", r.Measurements))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func maxInt(values []int) int {
+	var res int
+	for _, x := range values {
+		if x > res {
+			res = x
+		}
+	}
+	return res
+}
@@ -0,0 +1,60 @@
+package quantum
+
+import (
+	"fmt"
+	"testing"
+)
+
+func ExampleResourceCounter() {
+	r := NewResourceCounter(3)
+	CCNot(r, 0, 1, 2)
+	fmt.Println(r)
+
+	// Output:
+	// qubits: 3 (3 used)
+	// gates: 15 (CNOT: 6, H: 2, T: 4, T*: 3)
+	// T-count: 7
+	// depth: 11
+	// T-depth: 4
+}
+
+func TestResourceCounter(t *testing.T) {
+	r := NewResourceCounter(4)
+	H(r, 0)
+	T(r, 1)
+	InvT(r, 1)
+	r.CNot(0, 1)
+	T(r, 2)
+	r.Unitary(3, &Matrix2{0, 1i, 1i, 0})
+	X(r, 0)
+	if r.Gates() != 7 || r.CNots != 1 || r.TCount() != 3 {
+		t.Errorf("unexpected counts: %v", r)
+	}
+	if r.Unitaries["U"] != 1 || r.Unitaries["H"] != 1 || r.Unitaries["X"] != 1 {
+		t.Errorf("unexpected unitary counts: %v", r.Unitaries)
+	}
+	if r.Depth() != 4 {
+		t.Errorf("unexpected depth: %d", r.Depth())
+	}
+	if r.TDepth() != 2 {
+		t.Errorf("unexpected T-depth: %d", r.TDepth())
+	}
+	if r.BitUsage[1] != 3 || r.UsedBits() != 4 {
+		t.Errorf("unexpected usage: %v", r.BitUsage)
+	}
+}
+
+func TestResourceCounterLarge(t *testing.T) {
+	r := NewResourceCounter(100)
+	var control []int
+	for i := 1; i < 50; i++ {
+		control = append(control, i)
+	}
+	ToffoliN(r, 0, control...)
+	if r.TCount() == 0 || r.TCount()%7 != 0 {
+		t.Errorf("unexpected T-count: %d", r.TCount())
+	}
+	if r.TCount() != 7*r.CNots/6 {
+		t.Errorf("unexpected T-count %d for %d CNOTs", r.TCount(), r.CNots)
+	}
+}
@@ -0,0 +1,220 @@
+package quantum
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+)
+
+// An RXGate rotates a qubit by Theta radians around the X
+// axis of the Bloch sphere.
+type RXGate struct {
+	Bit   int
+	Theta float64
+}
+
+func (r *RXGate) Matrix() Matrix2 {
+	cos := complex(math.Cos(r.Theta/2), 0)
+	sin := complex(0, -math.Sin(r.Theta/2))
+	return Matrix2{cos, sin, sin, cos}
+}
+
+func (r *RXGate) String() string {
+	return fmt.Sprintf("RX(%d, %s)", r.Bit, formatAngle(r.Theta))
+}
+
+func (r *RXGate) Apply(c Computer) {
+	m := r.Matrix()
+	c.Unitary(r.Bit, &m)
+}
+
+func (r *RXGate) Inverse() Gate {
+	return &RXGate{Bit: r.Bit, Theta: -r.Theta}
+}
+
+// An RYGate rotates a qubit by Theta radians around the Y
+// axis of the Bloch sphere.
+type RYGate struct {
+	Bit   int
+	Theta float64
+}
+
+func (r *RYGate) Matrix() Matrix2 {
+	cos := complex(math.Cos(r.Theta/2), 0)
+	sin := complex(math.Sin(r.Theta/2), 0)
+	return Matrix2{cos, -sin, sin, cos}
+}
+
+func (r *RYGate) String() string {
+	return fmt.Sprintf("RY(%d, %s)", r.Bit, formatAngle(r.Theta))
+}
+
+func (r *RYGate) Apply(c Computer) {
+	m := r.Matrix()
+	c.Unitary(r.Bit, &m)
+}
+
+func (r *RYGate) Inverse() Gate {
+	return &RYGate{Bit: r.Bit, Theta: -r.Theta}
+}
+
+// An RZGate rotates a qubit by Theta radians around the Z
+// axis of the Bloch sphere.
+//
+// Unlike a PhaseGate, it applies a phase to both basis
+// states, which matters when it is controlled.
+type RZGate struct {
+	Bit   int
+	Theta float64
+}
+
+func (r *RZGate) Matrix() Matrix2 {
+	return Matrix2{
+		cmplx.Exp(complex(0, -r.Theta/2)), 0,
+		0, cmplx.Exp(complex(0, r.Theta/2)),
+	}
+}
+
+func (r *RZGate) String() string {
+	return fmt.Sprintf("RZ(%d, %s)", r.Bit, formatAngle(r.Theta))
+}
+
+func (r *RZGate) Apply(c Computer) {
+	m := r.Matrix()
+	c.Unitary(r.Bit, &m)
+}
+
+func (r *RZGate) Inverse() Gate {
+	return &RZGate{Bit: r.Bit, Theta: -r.Theta}
+}
+
+// A PhaseGate applies a phase of e^(i*Phi) to the 1 state
+// of a qubit. For example, Phi=pi/4 is a T gate.
+type PhaseGate struct {
+	Bit int
+	Phi float64
+}
+
+func (p *PhaseGate) Matrix() Matrix2 {
+	return Matrix2{1, 0, 0, cmplx.Exp(complex(0, p.Phi))}
+}
+
+func (p *PhaseGate) String() string {
+	return fmt.Sprintf("Phase(%d, %s)", p.Bit, formatAngle(p.Phi))
+}
+
+func (p *PhaseGate) Apply(c Computer) {
+	m := p.Matrix()
+	c.Unitary(p.Bit, &m)
+}
+
+func (p *PhaseGate) Inverse() Gate {
+	return &PhaseGate{Bit: p.Bit, Phi: -p.Phi}
+}
+
+// A U3Gate applies a generic single-qubit unitary, as in
+// OpenQASM:
+//
+//	[[cos(t/2), -e^(il)*sin(t/2)],
+//	 [e^(ip)*sin(t/2), e^(i(p+l))*cos(t/2)]]
+//
+// where t, p, and l are Theta, Phi, and Lambda.
+type U3Gate struct {
+	Bit    int
+	Theta  float64
+	Phi    float64
+	Lambda float64
+}
+
+func (u *U3Gate) Matrix() Matrix2 {
+	return u3Matrix(u.Theta, u.Phi, u.Lambda)
+}
+
+func (u *U3Gate) String() string {
+	return fmt.Sprintf("U3(%d, %s, %s, %s)", u.Bit, formatAngle(u.Theta), formatAngle(u.Phi),
+		formatAngle(u.Lambda))
+}
+
+func (u *U3Gate) Apply(c Computer) {
+	m := u.Matrix()
+	c.Unitary(u.Bit, &m)
+}
+
+func (u *U3Gate) Inverse() Gate {
+	return &U3Gate{Bit: u.Bit, Theta: -u.Theta, Phi: -u.Lambda, Lambda: -u.Phi}
+}
+
+// A ControlledGate applies a gate if the control qubit is
+// set. The gate must not act on the control qubit.
+type ControlledGate struct {
+	Control int
+	Gate    Gate
+}
+
+func (c *ControlledGate) String() string {
+	return fmt.Sprintf("C(%d, %s)", c.Control, c.Gate)
+}
+
+func (c *ControlledGate) Apply(comp Computer) {
+	Cond(comp, c.Control, c.Gate.Apply)
+}
+
+func (c *ControlledGate) Inverse() Gate {
+	return &ControlledGate{Control: c.Control, Gate: c.Gate.Inverse()}
+}
+
+// rotationGate creates a rotation gate from its name, as
+// used in its String(), and its angles.
+func rotationGate(name string, bit int, angles []float64) Gate {
+	switch name {
+	case "RX":
+		return &RXGate{Bit: bit, Theta: angles[0]}
+	case "RY":
+		return &RYGate{Bit: bit, Theta: angles[0]}
+	case "RZ":
+		return &RZGate{Bit: bit, Theta: angles[0]}
+	case "Phase":
+		return &PhaseGate{Bit: bit, Phi: angles[0]}
+	case "U3":
+		return &U3Gate{Bit: bit, Theta: angles[0], Phi: angles[1], Lambda: angles[2]}
+	}
+	panic("unknown rotation gate: " + name)
+}
+
+type controlledGateJSON struct {
+	Control int             `json:"control"`
+	Gate    json.RawMessage `json:"gate"`
+}
+
+// MarshalJSON encodes the gate with the inner gate in the
+// format of MarshalGate.
+func (c *ControlledGate) MarshalJSON() ([]byte, error) {
+	inner, err := MarshalGate(c.Gate)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&controlledGateJSON{Control: c.Control, Gate: inner})
+}
+
+// UnmarshalJSON decodes the format from MarshalJSON.
+func (c *ControlledGate) UnmarshalJSON(data []byte) error {
+	var obj controlledGateJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	inner, err := UnmarshalGate(obj.Gate)
+	if err != nil {
+		return err
+	}
+	c.Control = obj.Control
+	c.Gate = inner
+	return nil
+}
+
+// formatAngle formats an angle so that it can be parsed
+// back exactly.
+func formatAngle(theta float64) string {
+	return strconv.FormatFloat(theta, 'g', -1, 64)
+}
@@ -0,0 +1,157 @@
+package quantum
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRotationGateMatrices(t *testing.T) {
+	testCases := []struct {
+		Gate     Gate
+		Expected Matrix2
+	}{
+		{&RXGate{Theta: math.Pi}, Matrix2{0, -1i, -1i, 0}},
+		{&RYGate{Theta: math.Pi}, Matrix2{0, -1, 1, 0}},
+		{&RZGate{Theta: math.Pi}, Matrix2{-1i, 0, 0, 1i}},
+		{&PhaseGate{Phi: math.Pi / 4}, Matrix2{1, 0, 0, complex(math.Sqrt2/2, math.Sqrt2/2)}},
+		{
+			&U3Gate{Theta: math.Pi / 2, Phi: 0, Lambda: math.Pi},
+			Matrix2{math.Sqrt2 / 2, math.Sqrt2 / 2, math.Sqrt2 / 2, -math.Sqrt2 / 2},
+		},
+	}
+	for i, tc := range testCases {
+		actual := Record(1, tc.Gate.Apply)
+		expected := Circuit{&UnitaryGate{Matrix: tc.Expected}}
+		if !ExtractUnitary(1, actual).ApproxEqual(ExtractUnitary(1, expected), 1e-8) {
+			t.Errorf("case %d: incorrect matrix for %s", i, tc.Gate)
+		}
+	}
+}
+
+func TestRotationGateInverses(t *testing.T) {
+	gates := []Gate{
+		&RXGate{Bit: 1, Theta: rand.NormFloat64()},
+		&RYGate{Bit: 0, Theta: rand.NormFloat64()},
+		&RZGate{Bit: 2, Theta: rand.NormFloat64()},
+		&PhaseGate{Bit: 1, Phi: rand.NormFloat64()},
+		&U3Gate{Bit: 2, Theta: rand.NormFloat64(), Phi: rand.NormFloat64(),
+			Lambda: rand.NormFloat64()},
+		&ControlledGate{Control: 0, Gate: &U3Gate{Bit: 2, Theta: rand.NormFloat64(),
+			Phi: rand.NormFloat64(), Lambda: rand.NormFloat64()}},
+		&ControlledGate{Control: 1, Gate: Circuit{&HGate{Bit: 0}, &TGate{Bit: 2}}},
+	}
+	for _, g := range gates {
+		product := ExtractUnitary(3, Circuit{g, g.Inverse()})
+		if !product.ApproxEqual(NewMatrix(3), 1e-8) {
+			t.Errorf("incorrect inverse for %s", g)
+		}
+	}
+}
+
+func TestControlledGate(t *testing.T) {
+	m := RandomMatrix2()
+	u3 := &U3Gate{Bit: 1}
+	u3.Theta, u3.Phi, u3.Lambda = u3Angles(&m)
+	actual := ExtractUnitary(3, &ControlledGate{Control: 2, Gate: u3})
+	expected := ExtractUnitary(3, testFnGate(func(c Computer) {
+		um := u3.Matrix()
+		CUnitary(c, 2, 1, &um)
+	}))
+	if !actual.ApproxEqual(expected, 1e-8) {
+		t.Error("incorrect controlled U3")
+	}
+
+	// A controlled RZ differs from a controlled phase.
+	rz := ExtractUnitary(2, &ControlledGate{Control: 0, Gate: &RZGate{Bit: 1, Theta: 1}})
+	phase := ExtractUnitary(2, &ControlledGate{Control: 0, Gate: &PhaseGate{Bit: 1, Phi: 1}})
+	if rz.ApproxEqualPhase(phase, 1e-8) {
+		t.Error("controlled RZ should not match controlled phase")
+	}
+}
+
+func TestRotationGatesRoundTrip(t *testing.T) {
+	circuit := Circuit{
+		&RXGate{Bit: 0, Theta: rand.NormFloat64()},
+		&RYGate{Bit: 1, Theta: -1e-20},
+		&RZGate{Bit: 2, Theta: math.Pi},
+		&PhaseGate{Bit: 0, Phi: rand.NormFloat64()},
+		&U3Gate{Bit: 1, Theta: rand.NormFloat64(), Phi: 3e100, Lambda: rand.NormFloat64()},
+		&ControlledGate{Control: 2, Gate: &RYGate{Bit: 0, Theta: 0.25}},
+		&ControlledGate{Control: 1, Gate: Circuit{&HGate{Bit: 0}, &RZGate{Bit: 2, Theta: 2}}},
+	}
+
+	parsed, err := ParseCircuit(circuit.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != circuit.String() {
+		t.Errorf("expected %s but got %s", circuit, parsed)
+	}
+
+	data, err := json.Marshal(circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Circuit
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != circuit.String() {
+		t.Errorf("expected %s but got %s", circuit, decoded)
+	}
+}
+
+func TestRotationGatesQASM(t *testing.T) {
+	circuit := Circuit{
+		&RXGate{Bit: 0, Theta: rand.NormFloat64()},
+		&RYGate{Bit: 1, Theta: rand.NormFloat64()},
+		&RZGate{Bit: 2, Theta: rand.NormFloat64()},
+		&PhaseGate{Bit: 0, Phi: rand.NormFloat64()},
+		&U3Gate{Bit: 1, Theta: rand.NormFloat64(), Phi: rand.NormFloat64(),
+			Lambda: rand.NormFloat64()},
+		&ControlledGate{Control: 2, Gate: &RZGate{Bit: 0, Theta: rand.NormFloat64()}},
+		&ControlledGate{Control: 0, Gate: &PhaseGate{Bit: 1, Phi: rand.NormFloat64()}},
+		&ControlledGate{Control: 1, Gate: &U3Gate{Bit: 2, Theta: rand.NormFloat64(),
+			Phi: rand.NormFloat64(), Lambda: rand.NormFloat64()}},
+		&ControlledGate{Control: 1, Gate: &RXGate{Bit: 0, Theta: rand.NormFloat64()}},
+		&ControlledGate{Control: 2, Gate: &CNotGate{Control: 0, Target: 1}},
+	}
+	var buf bytes.Buffer
+	if err := WriteQASM(&buf, 3, circuit); err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := ParseQASM(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := parsed[0].(*RXGate); !ok {
+		t.Errorf("unexpected first gate: %s", parsed[0])
+	}
+	if !ExtractUnitary(3, parsed).ApproxEqualPhase(ExtractUnitary(3, circuit), 1e-8) {
+		t.Error("incorrect unitary")
+	}
+}
+
+func TestControlledGateRender(t *testing.T) {
+	params := DefaultRenderParams(3)
+	g := &ControlledGate{Control: 0, Gate: &RXGate{Bit: 2, Theta: 0.5}}
+	img, err := g.Render(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := img.Bounds().Dx() / 2
+	for _, y := range []int{params.QubitY(0), params.QubitY(1) - 10, params.QubitY(1) + 10} {
+		if img.RGBAAt(x, y).A == 0 {
+			t.Errorf("expected control wire at y=%d", y)
+		}
+	}
+
+	circuit := Circuit{g, &U3Gate{Bit: 1, Theta: 0.1, Phi: 0.2, Lambda: 0.3},
+		&ControlledGate{Control: 2, Gate: &HGate{Bit: 0}}}
+	if _, err := circuit.Render(params); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,241 @@
+package quantum
+
+import "sort"
+
+// A CouplingGraph describes the CNot gates that a piece
+// of hardware supports natively.
+type CouplingGraph struct {
+	NumBits int
+
+	// Edges maps each control qubit to the target qubits
+	// it may be used with, so CNot(i, j) is supported if j
+	// is in Edges[i].
+	Edges [][]int
+}
+
+// LinearCoupling creates a CouplingGraph for qubits in a
+// line, where qubit i can control qubit i+1.
+func LinearCoupling(numBits int) *CouplingGraph {
+	res := &CouplingGraph{NumBits: numBits, Edges: make([][]int, numBits)}
+	for i := 0; i+1 < numBits; i++ {
+		res.Edges[i] = []int{i + 1}
+	}
+	return res
+}
+
+// Supported checks if CNot(control, target) is native.
+func (g *CouplingGraph) Supported(control, target int) bool {
+	for _, t := range g.Edges[control] {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Connected checks if there is a CNot in either direction
+// between two qubits.
+func (g *CouplingGraph) Connected(a, b int) bool {
+	return g.Supported(a, b) || g.Supported(b, a)
+}
+
+// distances computes the number of edges between every
+// pair of qubits, ignoring the direction of edges.
+// Unreachable pairs have distance -1.
+func (g *CouplingGraph) distances() [][]int {
+	res := make([][]int, g.NumBits)
+	for start := range res {
+		dists := make([]int, g.NumBits)
+		for i := range dists {
+			dists[i] = -1
+		}
+		dists[start] = 0
+		queue := []int{start}
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			for next := 0; next < g.NumBits; next++ {
+				if dists[next] == -1 && g.Connected(node, next) {
+					dists[next] = dists[node] + 1
+					queue = append(queue, next)
+				}
+			}
+		}
+		res[start] = dists
+	}
+	return res
+}
+
+// A Routing is a circuit that has been rewritten to only
+// use CNot gates supported by a CouplingGraph.
+type Routing struct {
+	// Circuit acts on the physical qubits.
+	Circuit Circuit
+
+	// InitialLayout maps each logical qubit to the
+	// physical qubit where it starts.
+	InitialLayout []int
+
+	// FinalLayout maps each logical qubit to the physical
+	// qubit where it ends up, after swaps.
+	FinalLayout []int
+}
+
+// Route maps a circuit on numBits logical qubits onto the
+// physical qubits of a CouplingGraph.
+//
+// The circuit is expanded into primitive gates. An
+// initial layout is chosen greedily so that qubits which
+// interact often are placed close together. Swaps are
+// then inserted to bring the qubits of each CNot next
+// to each other, and CNots that go against the direction
+// of an edge are reversed with H gates.
+//
+// Swaps are emitted as three CNots, so every CNot in the
+// result lies on a directed edge of the graph.
+//
+// Layouts include every physical qubit, so logical qubits
+// numBits and beyond are unused in the original circuit.
+func Route(numBits int, c Circuit, g *CouplingGraph) *Routing {
+	if numBits > g.NumBits {
+		panic("coupling graph has too few qubits")
+	}
+	dists := g.distances()
+	for _, row := range dists {
+		for _, d := range row {
+			if d == -1 {
+				panic("coupling graph is disconnected")
+			}
+		}
+	}
+
+	gates := Record(numBits, c.Apply)
+	layout := initialLayout(gates, g, dists)
+	res := &Routing{InitialLayout: append([]int{}, layout...)}
+
+	// Inverse of layout, mapping physical to logical.
+	logical := make([]int, g.NumBits)
+	for l, p := range layout {
+		logical[p] = l
+	}
+
+	rec := NewRecorder(g.NumBits)
+	mapped := &MappedComputer{C: rec, Mapping: layout}
+	for _, gate := range gates {
+		cnot, ok := gate.(*CNotGate)
+		if !ok {
+			gate.Apply(mapped)
+			continue
+		}
+		control, target := layout[cnot.Control], layout[cnot.Target]
+		for dists[control][target] > 1 {
+			// Move the control one step towards the target.
+			var next int
+			for next = 0; next < g.NumBits; next++ {
+				if g.Connected(control, next) && dists[next][target] == dists[control][target]-1 {
+					break
+				}
+			}
+			rec.Circuit = appendSwap(rec.Circuit, g, control, next)
+			lc, ln := logical[control], logical[next]
+			layout[lc], layout[ln] = next, control
+			logical[control], logical[next] = ln, lc
+			control = next
+		}
+		rec.Circuit = appendCNot(rec.Circuit, g, control, target)
+	}
+
+	res.Circuit = rec.Circuit
+	res.FinalLayout = layout
+	return res
+}
+
+// appendCNot adds a CNot between connected qubits,
+// reversing it with H gates if only the other direction
+// is supported.
+func appendCNot(c Circuit, g *CouplingGraph, control, target int) Circuit {
+	if g.Supported(control, target) {
+		return append(c, &CNotGate{Control: control, Target: target})
+	}
+	return append(c,
+		&HGate{Bit: control},
+		&HGate{Bit: target},
+		&CNotGate{Control: target, Target: control},
+		&HGate{Bit: control},
+		&HGate{Bit: target},
+	)
+}
+
+// appendSwap adds a swap between connected qubits as
+// three CNots, with the middle one reversed.
+func appendSwap(c Circuit, g *CouplingGraph, a, b int) Circuit {
+	if !g.Supported(a, b) {
+		a, b = b, a
+	}
+	c = appendCNot(c, g, a, b)
+	c = appendCNot(c, g, b, a)
+	return appendCNot(c, g, a, b)
+}
+
+// initialLayout places logical qubits one at a time, in
+// order of how many CNots they are involved in. Each
+// qubit is placed on the free physical qubit closest to
+// the qubits it interacts with.
+func initialLayout(gates Circuit, g *CouplingGraph, dists [][]int) []int {
+	weights := make([][]int, g.NumBits)
+	for i := range weights {
+		weights[i] = make([]int, g.NumBits)
+	}
+	usage := make([]int, g.NumBits)
+	for _, gate := range gates {
+		if cnot, ok := gate.(*CNotGate); ok {
+			weights[cnot.Control][cnot.Target]++
+			weights[cnot.Target][cnot.Control]++
+			usage[cnot.Control]++
+			usage[cnot.Target]++
+		}
+	}
+	order := make([]int, g.NumBits)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return usage[order[i]] > usage[order[j]]
+	})
+
+	layout := make([]int, g.NumBits)
+	for i := range layout {
+		layout[i] = -1
+	}
+	taken := make([]bool, g.NumBits)
+	for i, l := range order {
+		best := -1
+		bestCost := -1
+		for p := 0; p < g.NumBits; p++ {
+			if taken[p] {
+				continue
+			}
+			var cost int
+			if i == 0 {
+				// Start with the best connected qubit.
+				for q := 0; q < g.NumBits; q++ {
+					if g.Connected(p, q) {
+						cost--
+					}
+				}
+			}
+			for other, w := range weights[l] {
+				if layout[other] != -1 {
+					cost += w * dists[p][layout[other]]
+				}
+			}
+			if best == -1 || cost < bestCost {
+				best = p
+				bestCost = cost
+			}
+		}
+		layout[l] = best
+		taken[best] = true
+	}
+	return layout
+}
@@ -0,0 +1,63 @@
+package quantum
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRoute(t *testing.T) {
+	ring := &CouplingGraph{NumBits: 6, Edges: [][]int{{1}, {2}, {3}, {4}, {5}, {0}}}
+	qx := &CouplingGraph{NumBits: 5, Edges: [][]int{{}, {0}, {0, 1}, {2, 4}, {2}}}
+	graphs := map[string]*CouplingGraph{
+		"Linear": LinearCoupling(7),
+		"Ring":   ring,
+		"QX":     qx,
+	}
+	for name, g := range graphs {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 5; i++ {
+				numBits := 5
+				circuit := randomizedCircuit(rand.Perm(numBits), rand.Int(), 20)
+				routing := Route(numBits, circuit, g)
+				for _, gate := range Record(g.NumBits, routing.Circuit.Apply) {
+					if !compliesWithCoupling(gate, g) {
+						t.Fatalf("gate %v is not supported", gate)
+					}
+				}
+
+				logical := RandomSimulation(g.NumBits)
+				physical := permuteSimulation(logical, routing.InitialLayout)
+				circuit.Apply(logical)
+				routing.Circuit.Apply(physical)
+				expected := permuteSimulation(logical, routing.FinalLayout)
+				if !physical.ApproxEqual(expected, 1e-8) {
+					t.Fatal("incorrect result")
+				}
+			}
+		})
+	}
+}
+
+func compliesWithCoupling(gate Gate, g *CouplingGraph) bool {
+	switch gate := gate.(type) {
+	case *CNotGate:
+		return g.Supported(gate.Control, gate.Target)
+	default:
+		return singleGateBit(toPhaseGate(gate)) != -1
+	}
+}
+
+// permuteSimulation moves each qubit i to qubit layout[i].
+func permuteSimulation(s *Simulation, layout []int) *Simulation {
+	res := NewSimulation(s.NumBits())
+	for i, ph := range s.Phases {
+		var j int
+		for bit, dest := range layout {
+			if i&(1<<uint(bit)) != 0 {
+				j |= 1 << uint(dest)
+			}
+		}
+		res.Phases[j] = ph
+	}
+	return res
+}
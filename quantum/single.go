@@ -0,0 +1,242 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"strings"
+)
+
+// singleEpsilon is the magnitude below which single
+// precision values are treated as rounding errors.
+const singleEpsilon = 1e-6
+
+// A SingleSimulation is like a Simulation, but it stores
+// amplitudes in single precision. This halves the memory
+// required for a given number of qubits, at the cost of
+// accumulating rounding errors faster.
+type SingleSimulation struct {
+	numBits int
+	Phases  []complex64
+}
+
+// Create a new SingleSimulation with all qubits set to 0.
+func NewSingleSimulation(numBits int) *SingleSimulation {
+	return NewSingleSimulationBits(numBits, 0)
+}
+
+// Create a new SingleSimulation with a given bit-string.
+func NewSingleSimulationBits(numBits int, value uint) *SingleSimulation {
+	s := &SingleSimulation{
+		numBits: numBits,
+		Phases:  make([]complex64, 1<<uint(numBits)),
+	}
+	s.Phases[int(value)] = 1
+	return s
+}
+
+// Create a new SingleSimulation by rounding the state of
+// a double precision Simulation.
+func NewSingleSimulationFrom(s *Simulation) *SingleSimulation {
+	res := &SingleSimulation{
+		numBits: s.NumBits(),
+		Phases:  make([]complex64, len(s.Phases)),
+	}
+	for i, ph := range s.Phases {
+		res.Phases[i] = complex64(ph)
+	}
+	return res
+}
+
+func (s *SingleSimulation) NumBits() int {
+	return s.numBits
+}
+
+func (s *SingleSimulation) InUse(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= s.numBits {
+		panic("bit index out of range")
+	}
+	return false
+}
+
+func (s *SingleSimulation) Measure(bitIdx int) bool {
+	var zeroProb float64
+	var oneProb float64
+	for i, ph := range s.Phases {
+		prob := float64(real(ph))*float64(real(ph)) + float64(imag(ph))*float64(imag(ph))
+		if i&(1<<uint(bitIdx)) != 0 {
+			oneProb += prob
+		} else {
+			zeroProb += prob
+		}
+	}
+	isOne := rand.Float64()*(zeroProb+oneProb) > zeroProb
+	var scale float32
+	if isOne {
+		scale = float32(1 / math.Sqrt(oneProb))
+	} else {
+		scale = float32(1 / math.Sqrt(zeroProb))
+	}
+	for i := range s.Phases {
+		if (i&(1<<uint(bitIdx)) != 0) != isOne {
+			s.Phases[i] = 0
+		} else {
+			s.Phases[i] *= complex(scale, 0)
+		}
+	}
+	return isOne
+}
+
+func (s *SingleSimulation) Unitary(target int, m *Matrix2) {
+	if target < 0 || target >= s.numBits {
+		panic("bit index out of range")
+	}
+	m11, m12 := complex64(m.M11), complex64(m.M12)
+	m21, m22 := complex64(m.M21), complex64(m.M22)
+
+	// Optimization for T-like gates.
+	if m.M11 == 1 && m.M12 == 0 && m.M21 == 0 {
+		for i := range s.Phases {
+			if i&(1<<uint(target)) == 0 {
+				continue
+			}
+			s.Phases[i] *= m22
+		}
+		return
+	}
+
+	for i := range s.Phases {
+		if i&(1<<uint(target)) != 0 {
+			continue
+		}
+		other := i | (1 << uint(target))
+		p0 := s.Phases[i]
+		p1 := s.Phases[other]
+		s.Phases[i] = m11*p0 + m12*p1
+		s.Phases[other] = m21*p0 + m22*p1
+	}
+}
+
+func (s *SingleSimulation) CNot(control, target int) {
+	if control < 0 || control >= s.numBits || target < 0 || target >= s.numBits {
+		panic("bit index out of range")
+	}
+	if control == target {
+		panic("overlapping control and target is not invertible")
+	}
+	controlMask := 1 << uint(control)
+	targetMask := 1 << uint(target)
+	for i := range s.Phases {
+		if i&controlMask != 0 && i&targetMask == 0 {
+			other := i | targetMask
+			s.Phases[i], s.Phases[other] = s.Phases[other], s.Phases[i]
+		}
+	}
+}
+
+func (s *SingleSimulation) Phase(value []bool) complex64 {
+	var idx int
+	for i, x := range value {
+		if x {
+			idx |= 1 << uint(i)
+		}
+	}
+	return s.Phases[idx]
+}
+
+func (s *SingleSimulation) Copy() *SingleSimulation {
+	return &SingleSimulation{
+		numBits: s.numBits,
+		Phases:  append([]complex64{}, s.Phases...),
+	}
+}
+
+func (s *SingleSimulation) ApproxEqual(s1 *SingleSimulation, tol float64) bool {
+	for i, phase := range s.Phases {
+		if cmplx.Abs(complex128(phase-s1.Phases[i])) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *SingleSimulation) String() string {
+	pieces := []string{}
+	for i, phase := range s.Phases {
+		re, im := float64(real(phase)), float64(imag(phase))
+		if math.Abs(re) < singleEpsilon {
+			re = 0
+		}
+		if math.Abs(im) < singleEpsilon {
+			im = 0
+		}
+		if re == 0 && im == 0 {
+			continue
+		}
+		pieces = append(pieces, formatPhase(complex(re, im))+classicalString(s.numBits, i))
+	}
+	return strings.Join(pieces, " + ")
+}
+
+func (s *SingleSimulation) Sample() []bool {
+	var res []bool
+	for i := 0; i < s.numBits; i++ {
+		res = append(res, s.Measure(i))
+	}
+	return res
+}
+
+// ControlGate runs the gate g on states where control is
+// not set.
+// The gate g must not modify the control bit.
+func (s *SingleSimulation) ControlGate(control int, g Gate) {
+	s1 := s.Copy()
+	for i := range s.Phases {
+		if i&(1<<uint(control)) == 0 {
+			s1.Phases[i] = 0
+		} else {
+			s.Phases[i] = 0
+		}
+	}
+	g.Apply(s1)
+	for i, phase := range s1.Phases {
+		if phase != 0 && s.Phases[i] != 0 {
+			panic("gate must not modify control bit")
+		}
+		s.Phases[i] += phase
+	}
+}
+
+// Simulation converts the state to double precision.
+func (s *SingleSimulation) Simulation() *Simulation {
+	res := &Simulation{
+		numBits: s.numBits,
+		Phases:  make([]complex128, len(s.Phases)),
+	}
+	for i, ph := range s.Phases {
+		res.Phases[i] = complex128(ph)
+	}
+	return res
+}
+
+// PrecisionDivergence measures how much rounding error a
+// circuit accumulates in single precision.
+//
+// It applies a to copies of s in both single and double
+// precision, and reports the largest absolute difference
+// between any two amplitudes, as well as the infidelity
+// 1-|<double|single>|^2 between the resulting states.
+func PrecisionDivergence(s *Simulation, a Applier) (maxError, infidelity float64) {
+	double := s.Copy()
+	single := NewSingleSimulationFrom(s)
+	a.Apply(double)
+	a.Apply(single)
+	var overlap complex128
+	for i, ph := range double.Phases {
+		ph1 := complex128(single.Phases[i])
+		maxError = math.Max(maxError, cmplx.Abs(ph-ph1))
+		overlap += cmplx.Conj(ph) * ph1
+	}
+	infidelity = 1 - math.Pow(cmplx.Abs(overlap), 2)
+	return
+}
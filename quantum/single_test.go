@@ -0,0 +1,49 @@
+package quantum
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func ExampleSingleSimulation() {
+	s := NewSingleSimulation(2)
+	X(s, 1)
+	H(s, 0)
+	H(s, 1)
+	s.CNot(0, 1)
+	fmt.Println(s)
+
+	// Output:
+	// 0.5|00> + -0.5|10> + -0.5|01> + 0.5|11>
+}
+
+func TestSingleSimulation(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		s := RandomSimulation(8)
+		single := NewSingleSimulationFrom(s)
+		circuit := randomizedCircuit(rand.Perm(8), rand.Int(), 50)
+		circuit.Apply(s)
+		circuit.Apply(single)
+		if !single.Simulation().ApproxEqual(s, 1e-5) {
+			t.Fatal("incorrect result")
+		}
+		single.ControlGate(3, &HGate{Bit: 1})
+		s.ControlGate(3, &HGate{Bit: 1})
+		if !single.Simulation().ApproxEqual(s, 1e-5) {
+			t.Fatal("incorrect control gate result")
+		}
+	}
+}
+
+func TestPrecisionDivergence(t *testing.T) {
+	s := RandomSimulation(10)
+	circuit := randomizedCircuit(rand.Perm(10), 1337, 200)
+	maxError, infidelity := PrecisionDivergence(s, circuit)
+	if maxError == 0 || maxError > 1e-4 {
+		t.Error("unexpected max error", maxError)
+	}
+	if infidelity > 1e-4 {
+		t.Error("unexpected infidelity", infidelity)
+	}
+}
@@ -0,0 +1,394 @@
+package quantum
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"math"
+	"math/cmplx"
+)
+
+// SolovayKitaevMaxDepth is the maximum recursion depth
+// used by SolovayKitaev before giving up.
+const SolovayKitaevMaxDepth = 8
+
+// An EpsilonNet is a table of H/T circuits whose
+// unitaries cover the single-qubit unitaries, used as the
+// base case for SolovayKitaev.
+//
+// Building a large net is slow, so it may be saved with
+// Save and reloaded with LoadEpsilonNet.
+type EpsilonNet struct {
+	words    []string
+	matrices []Matrix2
+
+	// quaternions caches su2Quaternion for each matrix,
+	// since every lookup compares against all of them.
+	quaternions [][4]float64
+}
+
+// NewEpsilonNet creates an EpsilonNet containing every
+// unitary (up to global phase) that can be reached with
+// at most maxLength gates from H, T, and T*.
+//
+// Nets with maxLength around 12 to 16 are typical, and
+// larger nets produce shorter circuits at the cost of
+// memory and lookup time.
+func NewEpsilonNet(maxLength int) *EpsilonNet {
+	gates := map[byte]Matrix2{
+		'H': {complex(1/math.Sqrt2, 0), complex(1/math.Sqrt2, 0),
+			complex(1/math.Sqrt2, 0), complex(-1/math.Sqrt2, 0)},
+		'T': {1, 0, 0, cmplx.Exp(complex(0, math.Pi/4))},
+		't': {1, 0, 0, cmplx.Exp(complex(0, -math.Pi/4))},
+	}
+	redundant := map[string]bool{"HH": true, "Tt": true, "tT": true}
+
+	words := []string{""}
+	matrices := []Matrix2{NewMatrix2()}
+	seen := map[[4]int64]bool{netKey(&matrices[0]): true}
+	level := []int{0}
+	for length := 1; length <= maxLength; length++ {
+		var nextLevel []int
+		for _, idx := range level {
+			word := words[idx]
+			for _, g := range []byte("HTt") {
+				if word != "" && redundant[word[len(word)-1:]+string(g)] {
+					continue
+				}
+				m := gates[g]
+				m.Mul(&matrices[idx])
+				key := netKey(&m)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				nextLevel = append(nextLevel, len(words))
+				words = append(words, word+string(g))
+				matrices = append(matrices, m)
+			}
+		}
+		level = nextLevel
+	}
+	return newEpsilonNet(words, matrices)
+}
+
+func newEpsilonNet(words []string, matrices []Matrix2) *EpsilonNet {
+	quaternions := make([][4]float64, len(matrices))
+	for i := range matrices {
+		quaternions[i] = su2Quaternion(&matrices[i])
+	}
+	return &EpsilonNet{words: words, matrices: matrices, quaternions: quaternions}
+}
+
+// LoadEpsilonNet decodes a net that was encoded with
+// EpsilonNet.Save.
+func LoadEpsilonNet(r io.Reader) (*EpsilonNet, error) {
+	var obj epsilonNetGob
+	if err := gob.NewDecoder(r).Decode(&obj); err != nil {
+		return nil, err
+	}
+	if len(obj.Words) != len(obj.Matrices) {
+		return nil, errors.New("load epsilon net: mismatched words and matrices")
+	}
+	return newEpsilonNet(obj.Words, obj.Matrices), nil
+}
+
+// Save encodes the net so that it can be loaded with
+// LoadEpsilonNet.
+func (e *EpsilonNet) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(&epsilonNetGob{Words: e.words, Matrices: e.matrices})
+}
+
+// Len gets the number of circuits in the net.
+func (e *EpsilonNet) Len() int {
+	return len(e.words)
+}
+
+// Nearest finds the circuit in the net which is closest
+// to m up to global phase, and returns the circuit's
+// unitary.
+func (e *EpsilonNet) Nearest(m *Matrix2) (Circuit, Matrix2) {
+	target := su2Quaternion(m)
+	var bestIdx int
+	var bestDot float64
+	for i, q := range e.quaternions {
+		dot := math.Abs(q[0]*target[0] + q[1]*target[1] + q[2]*target[2] + q[3]*target[3])
+		if dot > bestDot {
+			bestDot = dot
+			bestIdx = i
+		}
+	}
+	return wordCircuit(e.words[bestIdx]), e.matrices[bestIdx]
+}
+
+type epsilonNetGob struct {
+	Words    []string
+	Matrices []Matrix2
+}
+
+// SolovayKitaev approximates a single-qubit unitary with
+// a circuit of HGates and TGates on qubit 0, such that
+// the PhaseDistance between the circuit's unitary and m
+// is less than epsilon.
+//
+// The recursion depth is increased until the precision
+// is reached. An error is returned if the precision is
+// not reached by SolovayKitaevMaxDepth, which may happen
+// if the net is too coarse.
+func SolovayKitaev(net *EpsilonNet, m *Matrix2, epsilon float64) (Circuit, error) {
+	for depth := 0; depth <= SolovayKitaevMaxDepth; depth++ {
+		c, approx := solovayKitaev(net, m, depth)
+		if approx.PhaseDistance(m) < epsilon {
+			return simplifyCliffordT(c, true), nil
+		}
+	}
+	return nil, errors.New("Solovay-Kitaev: precision not reached")
+}
+
+func solovayKitaev(net *EpsilonNet, m *Matrix2, depth int) (Circuit, Matrix2) {
+	if depth == 0 {
+		return net.Nearest(m)
+	}
+	uCirc, uMat := solovayKitaev(net, m, depth-1)
+
+	delta := *m
+	uInv := uMat
+	uInv.ConjTranspose()
+	delta.Mul(&uInv)
+
+	v, w := groupCommutator(&delta)
+	vCirc, vMat := solovayKitaev(net, &v, depth-1)
+	wCirc, wMat := solovayKitaev(net, &w, depth-1)
+
+	// The result is V*W*V^H*W^H*U as a matrix product, so
+	// the gates are applied in the reverse order.
+	vInv, wInv := vMat, wMat
+	vInv.ConjTranspose()
+	wInv.ConjTranspose()
+	res := vMat
+	res.Mul(&wMat)
+	res.Mul(&vInv)
+	res.Mul(&wInv)
+	res.Mul(&uMat)
+	circuit := Circuit{uCirc, wCirc.Inverse(), vCirc.Inverse(), wCirc, vCirc}
+	return circuit, res
+}
+
+// groupCommutator finds V and W such that V*W*V^H*W^H is
+// m, using the balanced decomposition from Dawson and
+// Nielsen, "The Solovay-Kitaev algorithm" (2005).
+func groupCommutator(m *Matrix2) (Matrix2, Matrix2) {
+	axis, theta := su2AxisAngle(m)
+
+	// Rotations by phi around X and Y have a commutator
+	// which is a rotation by theta.
+	s := math.Sqrt((1 - math.Cos(theta/2)) / 2)
+	phi := 2 * math.Asin(math.Sqrt(s))
+	v := su2Rotation([3]float64{1, 0, 0}, phi)
+	w := su2Rotation([3]float64{0, 1, 0}, phi)
+
+	commutator := v
+	vInv, wInv := v, w
+	vInv.ConjTranspose()
+	wInv.ConjTranspose()
+	commutator.Mul(&w)
+	commutator.Mul(&vInv)
+	commutator.Mul(&wInv)
+	commAxis, _ := su2AxisAngle(&commutator)
+
+	// Conjugate by a rotation from commAxis to axis.
+	similarity := rotationBetween(commAxis, axis)
+	simInv := similarity
+	simInv.ConjTranspose()
+	for _, x := range []*Matrix2{&v, &w} {
+		res := similarity
+		res.Mul(x)
+		res.Mul(&simInv)
+		*x = res
+	}
+	return v, w
+}
+
+// su2Quaternion converts a unitary to SU(2), represented
+// as the unit quaternion (Re M11, Im M11, Re M21, Im M21).
+// The sign of the quaternion is arbitrary.
+func su2Quaternion(m *Matrix2) [4]float64 {
+	phase := cmplx.Sqrt(m.Det())
+	a := m.M11 / phase
+	b := m.M21 / phase
+	return [4]float64{real(a), imag(a), real(b), imag(b)}
+}
+
+// su2AxisAngle finds the axis and angle in [0, pi] of the
+// Bloch sphere rotation performed by a unitary.
+func su2AxisAngle(m *Matrix2) ([3]float64, float64) {
+	phase := cmplx.Sqrt(m.Det())
+	s := Matrix2{m.M11 / phase, m.M12 / phase, m.M21 / phase, m.M22 / phase}
+	cos := real(s.Trace()) / 2
+	if cos < 0 {
+		s = Matrix2{-s.M11, -s.M12, -s.M21, -s.M22}
+		cos = -cos
+	}
+	// s = cos(theta/2)*I - i*sin(theta/2)*(n . sigma)
+	axis := [3]float64{
+		-(imag(s.M12) + imag(s.M21)) / 2,
+		(real(s.M21) - real(s.M12)) / 2,
+		(imag(s.M22) - imag(s.M11)) / 2,
+	}
+	sin := math.Sqrt(axis[0]*axis[0] + axis[1]*axis[1] + axis[2]*axis[2])
+	theta := 2 * math.Atan2(sin, math.Min(1, cos))
+	if sin < 1e-15 {
+		return [3]float64{0, 0, 1}, theta
+	}
+	for i := range axis {
+		axis[i] /= sin
+	}
+	return axis, theta
+}
+
+// su2Rotation creates the SU(2) matrix for a rotation by
+// theta around a unit axis.
+func su2Rotation(axis [3]float64, theta float64) Matrix2 {
+	cos := complex(math.Cos(theta/2), 0)
+	sin := math.Sin(theta / 2)
+	nx, ny, nz := axis[0]*sin, axis[1]*sin, axis[2]*sin
+	return Matrix2{
+		cos - complex(0, nz), complex(-ny, -nx),
+		complex(ny, -nx), cos + complex(0, nz),
+	}
+}
+
+// rotationBetween creates a rotation taking the unit
+// vector from to the unit vector to.
+func rotationBetween(from, to [3]float64) Matrix2 {
+	cross := [3]float64{
+		from[1]*to[2] - from[2]*to[1],
+		from[2]*to[0] - from[0]*to[2],
+		from[0]*to[1] - from[1]*to[0],
+	}
+	dot := from[0]*to[0] + from[1]*to[1] + from[2]*to[2]
+	norm := math.Sqrt(cross[0]*cross[0] + cross[1]*cross[1] + cross[2]*cross[2])
+	if norm < 1e-12 {
+		if dot > 0 {
+			return NewMatrix2()
+		}
+		// Rotate by pi around any perpendicular axis.
+		perp := [3]float64{1, 0, 0}
+		if math.Abs(from[0]) > 0.9 {
+			perp = [3]float64{0, 1, 0}
+		}
+		cross = [3]float64{
+			from[1]*perp[2] - from[2]*perp[1],
+			from[2]*perp[0] - from[0]*perp[2],
+			from[0]*perp[1] - from[1]*perp[0],
+		}
+		norm = math.Sqrt(cross[0]*cross[0] + cross[1]*cross[1] + cross[2]*cross[2])
+	}
+	for i := range cross {
+		cross[i] /= norm
+	}
+	return su2Rotation(cross, math.Atan2(norm, dot))
+}
+
+// netKey rounds the SU(2) form of a unitary, up to sign,
+// so that equivalent unitaries have the same key.
+func netKey(m *Matrix2) [4]int64 {
+	q := su2Quaternion(m)
+	for _, x := range q {
+		if math.Abs(x) > 1e-6 {
+			if x < 0 {
+				for i := range q {
+					q[i] = -q[i]
+				}
+			}
+			break
+		}
+	}
+	var key [4]int64
+	for i, x := range q {
+		key[i] = int64(math.Round(x * 1e6))
+	}
+	return key
+}
+
+// wordCircuit converts a string of 'H', 'T', and 't'
+// (T*) into a circuit on qubit 0.
+func wordCircuit(word string) Circuit {
+	res := make(Circuit, len(word))
+	for i, g := range []byte(word) {
+		switch g {
+		case 'H':
+			res[i] = &HGate{}
+		case 'T':
+			res[i] = &TGate{}
+		case 't':
+			res[i] = &TGate{Conjugate: true}
+		}
+	}
+	return res
+}
+
+// simplifyCliffordT flattens a circuit of H, S, Z, and T
+// gates, cancelling adjacent H gates and combining runs of
+// phase gates modulo T^8 = I.
+//
+// If tOnly is set, runs of phase gates are written as T
+// gates rather than S and Z gates.
+func simplifyCliffordT(c Circuit, tOnly bool) Circuit {
+	type run struct {
+		isH     bool
+		eighths int
+	}
+	var stack []run
+	pushPhase := func(eighths int) {
+		if len(stack) > 0 && !stack[len(stack)-1].isH {
+			top := &stack[len(stack)-1]
+			top.eighths = (top.eighths + eighths) % 8
+			if top.eighths == 0 {
+				stack = stack[:len(stack)-1]
+			}
+		} else {
+			stack = append(stack, run{eighths: eighths})
+		}
+	}
+	var push func(g Gate)
+	push = func(g Gate) {
+		switch g := g.(type) {
+		case Circuit:
+			for _, sub := range g {
+				push(sub)
+			}
+		case *HGate:
+			if len(stack) > 0 && stack[len(stack)-1].isH {
+				stack = stack[:len(stack)-1]
+			} else {
+				stack = append(stack, run{isH: true})
+			}
+		default:
+			if p, ok := toPhaseGate(g).(*phaseGate); ok {
+				pushPhase(p.Eighths)
+			} else {
+				panic("unexpected gate: " + g.String())
+			}
+		}
+	}
+	push(c)
+
+	var res Circuit
+	for _, r := range stack {
+		if r.isH {
+			res = append(res, &HGate{})
+		} else if !tOnly {
+			res = append(res, phaseGates(0, r.eighths)...)
+		} else if r.eighths <= 4 {
+			for i := 0; i < r.eighths; i++ {
+				res = append(res, &TGate{})
+			}
+		} else {
+			for i := r.eighths; i < 8; i++ {
+				res = append(res, &TGate{Conjugate: true})
+			}
+		}
+	}
+	return res
+}
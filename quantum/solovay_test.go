@@ -0,0 +1,99 @@
+package quantum
+
+import (
+	"bytes"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestEpsilonNet(t *testing.T) {
+	net := NewEpsilonNet(6)
+	if net.Len() < 50 {
+		t.Fatalf("unexpectedly small net: %d", net.Len())
+	}
+	for i := 0; i < 10; i++ {
+		m := RandomMatrix2()
+		c, approx := net.Nearest(&m)
+		if actual := circuitMatrix2(c); actual.PhaseDistance(&approx) > 1e-8 {
+			t.Error("incorrect matrix for nearest circuit")
+		}
+		for j := range net.matrices {
+			if net.matrices[j].PhaseDistance(&m) < approx.PhaseDistance(&m)-1e-8 {
+				t.Fatal("found a closer matrix")
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := net.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadEpsilonNet(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != net.Len() {
+		t.Fatalf("expected %d entries but got %d", net.Len(), loaded.Len())
+	}
+	for i, word := range net.words {
+		if loaded.words[i] != word || loaded.matrices[i] != net.matrices[i] {
+			t.Fatalf("entry %d differs", i)
+		}
+	}
+}
+
+func TestGroupCommutator(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		axis := [3]float64{1, 2, -3}
+		norm := math.Sqrt(14)
+		for j := range axis {
+			axis[j] /= norm
+		}
+		m := su2Rotation(axis, 0.1*float64(i))
+		v, w := groupCommutator(&m)
+		vInv, wInv := v, w
+		vInv.ConjTranspose()
+		wInv.ConjTranspose()
+		product := v
+		product.Mul(&w)
+		product.Mul(&vInv)
+		product.Mul(&wInv)
+		if d := product.PhaseDistance(&m); d > 1e-8 {
+			t.Errorf("angle %f: distance %f", 0.1*float64(i), d)
+		}
+	}
+}
+
+func TestSolovayKitaev(t *testing.T) {
+	net := NewEpsilonNet(10)
+	targets := []Matrix2{
+		{1, 0, 0, cmplx.Exp(complex(0, math.Pi/8))},
+		RandomMatrix2(),
+		RandomMatrix2(),
+	}
+	for _, epsilon := range []float64{1e-2, 1e-4} {
+		for i, target := range targets {
+			c, err := SolovayKitaev(net, &target, epsilon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, g := range c {
+				switch g.(type) {
+				case *HGate, *TGate:
+				default:
+					t.Fatalf("unexpected gate: %s", g)
+				}
+			}
+			actual := circuitMatrix2(c)
+			if d := actual.PhaseDistance(&target); d >= epsilon {
+				t.Errorf("target %d: expected distance below %e but got %e", i, epsilon, d)
+			}
+		}
+	}
+}
+
+func circuitMatrix2(c Circuit) Matrix2 {
+	m := ExtractUnitary(1, c)
+	return Matrix2{m.At(0, 0), m.At(0, 1), m.At(1, 0), m.At(1, 1)}
+}
@@ -0,0 +1,219 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+)
+
+const (
+	// DefaultSparseThreshold is the default magnitude
+	// below which a SparseSimulation prunes amplitudes.
+	DefaultSparseThreshold = 1e-12
+
+	// maxDenseBits is the largest number of qubits for
+	// which a sparse state is converted to a dense one.
+	maxDenseBits = 30
+)
+
+// A SparseSimulation is a classical simulation of a
+// quantum computer that only stores non-zero amplitudes.
+//
+// This is efficient for mostly-classical circuits, like
+// arithmetic on basis states, which only ever touch a few
+// basis states even on many qubits. It supports up to 64
+// qubits.
+type SparseSimulation struct {
+	numBits int
+
+	// Phases maps basis states to amplitudes. Basis states
+	// are stored lowest-bit first, like Reg.Extract and
+	// Reg.Inject expect. Missing states have amplitude 0.
+	Phases map[uint]complex128
+
+	// Threshold is the magnitude below which amplitudes
+	// are pruned after each operation.
+	Threshold float64
+}
+
+// Create a new SparseSimulation with all qubits set to 0.
+func NewSparseSimulation(numBits int) *SparseSimulation {
+	return NewSparseSimulationBits(numBits, 0)
+}
+
+// Create a new SparseSimulation with a given bit-string.
+func NewSparseSimulationBits(numBits int, value uint) *SparseSimulation {
+	if numBits > 64 {
+		panic("too many qubits")
+	}
+	return &SparseSimulation{
+		numBits:   numBits,
+		Phases:    map[uint]complex128{value: 1},
+		Threshold: DefaultSparseThreshold,
+	}
+}
+
+// Create a new SparseSimulation from a dense Simulation.
+func NewSparseSimulationDense(s *Simulation) *SparseSimulation {
+	res := &SparseSimulation{
+		numBits:   s.NumBits(),
+		Phases:    map[uint]complex128{},
+		Threshold: DefaultSparseThreshold,
+	}
+	for i, ph := range s.Phases {
+		if cmplx.Abs(ph) >= res.Threshold {
+			res.Phases[uint(i)] = ph
+		}
+	}
+	return res
+}
+
+func (s *SparseSimulation) NumBits() int {
+	return s.numBits
+}
+
+func (s *SparseSimulation) InUse(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= s.numBits {
+		panic("bit index out of range")
+	}
+	return false
+}
+
+func (s *SparseSimulation) Measure(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= s.numBits {
+		panic("bit index out of range")
+	}
+	mask := uint(1) << uint(bitIdx)
+	var zeroProb, oneProb float64
+	for state, ph := range s.Phases {
+		prob := math.Pow(cmplx.Abs(ph), 2)
+		if state&mask != 0 {
+			oneProb += prob
+		} else {
+			zeroProb += prob
+		}
+	}
+	isOne := rand.Float64()*(zeroProb+oneProb) > zeroProb
+	var scale float64
+	if isOne {
+		scale = 1 / math.Sqrt(oneProb)
+	} else {
+		scale = 1 / math.Sqrt(zeroProb)
+	}
+	for state, ph := range s.Phases {
+		if (state&mask != 0) != isOne {
+			delete(s.Phases, state)
+		} else {
+			s.Phases[state] = ph * complex(scale, 0)
+		}
+	}
+	return isOne
+}
+
+func (s *SparseSimulation) Unitary(target int, m *Matrix2) {
+	if target < 0 || target >= s.numBits {
+		panic("bit index out of range")
+	}
+	mask := uint(1) << uint(target)
+
+	// Diagonal gates never create new basis states.
+	if m.M12 == 0 && m.M21 == 0 {
+		for state, ph := range s.Phases {
+			if state&mask == 0 {
+				s.Phases[state] = ph * m.M11
+			} else {
+				s.Phases[state] = ph * m.M22
+			}
+		}
+		return
+	}
+
+	newPhases := make(map[uint]complex128, len(s.Phases))
+	for state := range s.Phases {
+		state0 := state &^ mask
+		state1 := state | mask
+		if _, ok := s.Phases[state0]; ok && state == state1 {
+			// This pair is handled when we visit state0.
+			continue
+		}
+		p0 := s.Phases[state0]
+		p1 := s.Phases[state1]
+		s.setPhase(newPhases, state0, m.M11*p0+m.M12*p1)
+		s.setPhase(newPhases, state1, m.M21*p0+m.M22*p1)
+	}
+	s.Phases = newPhases
+}
+
+func (s *SparseSimulation) CNot(control, target int) {
+	if control < 0 || control >= s.numBits || target < 0 || target >= s.numBits {
+		panic("bit index out of range")
+	}
+	if control == target {
+		panic("overlapping control and target is not invertible")
+	}
+	controlMask := uint(1) << uint(control)
+	targetMask := uint(1) << uint(target)
+	newPhases := make(map[uint]complex128, len(s.Phases))
+	for state, ph := range s.Phases {
+		if state&controlMask != 0 {
+			state ^= targetMask
+		}
+		newPhases[state] = ph
+	}
+	s.Phases = newPhases
+}
+
+// Phase gets the amplitude of a basis state.
+func (s *SparseSimulation) Phase(value uint) complex128 {
+	return s.Phases[value]
+}
+
+func (s *SparseSimulation) Copy() *SparseSimulation {
+	res := &SparseSimulation{
+		numBits:   s.numBits,
+		Phases:    make(map[uint]complex128, len(s.Phases)),
+		Threshold: s.Threshold,
+	}
+	for state, ph := range s.Phases {
+		res.Phases[state] = ph
+	}
+	return res
+}
+
+func (s *SparseSimulation) ApproxEqual(s1 *SparseSimulation, tol float64) bool {
+	for state, ph := range s.Phases {
+		if cmplx.Abs(ph-s1.Phases[state]) > tol {
+			return false
+		}
+	}
+	for state, ph := range s1.Phases {
+		if _, ok := s.Phases[state]; !ok && cmplx.Abs(ph) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// Simulation converts the state into a dense Simulation.
+//
+// This panics if there are too many qubits to store the
+// dense state vector.
+func (s *SparseSimulation) Simulation() *Simulation {
+	if s.numBits > maxDenseBits {
+		panic("too many qubits for a dense simulation")
+	}
+	res := &Simulation{
+		numBits: s.numBits,
+		Phases:  make([]complex128, 1<<uint(s.numBits)),
+	}
+	for state, ph := range s.Phases {
+		res.Phases[state] = ph
+	}
+	return res
+}
+
+func (s *SparseSimulation) setPhase(phases map[uint]complex128, state uint, ph complex128) {
+	if cmplx.Abs(ph) >= s.Threshold {
+		phases[state] = ph
+	}
+}
@@ -0,0 +1,78 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+func TestSparseSimulationGates(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		s := RandomSimulation(6)
+		sparse := NewSparseSimulationDense(s)
+		for j := 0; j < 30; j++ {
+			g := randomizedCircuit(rand.Perm(6), rand.Int(), 1)
+			g.Apply(s)
+			g.Apply(sparse)
+		}
+		if !sparse.Simulation().ApproxEqual(s, 1e-8) {
+			t.Fatal("incorrect state")
+		}
+	}
+}
+
+func TestSparseSimulationModAdd(t *testing.T) {
+	const numBits = 16
+	source, target, modulus := make(Reg, numBits), make(Reg, numBits), make(Reg, numBits)
+	for i := 0; i < numBits; i++ {
+		source[i] = i
+		target[i] = i + numBits
+		modulus[i] = i + numBits*2
+	}
+	working := numBits * 3
+
+	const mod = 50021
+	const targetValue = 49000
+	var state uint
+	state = source.Inject(state, 48000)
+	state = target.Inject(state, targetValue)
+	state = modulus.Inject(state, mod)
+
+	s := NewSparseSimulationBits(numBits*3+1, state)
+
+	// Put the lowest source bits in superposition.
+	for i := 0; i < 3; i++ {
+		H(s, source[i])
+	}
+	ModAdd(s, source, target, modulus, working)
+
+	if len(s.Phases) != 8 {
+		t.Fatalf("expected 8 states but got %d", len(s.Phases))
+	}
+	for state, ph := range s.Phases {
+		if math.Abs(cmplx.Abs(ph)-1/math.Sqrt(8)) > 1e-8 {
+			t.Errorf("unexpected amplitude %f", ph)
+		}
+		x := source.Extract(state)
+		y := target.Extract(state)
+		if y != (x+targetValue)%mod || modulus.Extract(state) != mod || state&(1<<working) != 0 {
+			t.Errorf("unexpected state: %d + %d = %d", x, targetValue, y)
+		}
+	}
+}
+
+func TestSparseSimulationMeasure(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		s := NewSparseSimulation(60)
+		H(s, 59)
+		s.CNot(59, 3)
+		res := s.Measure(3)
+		if s.Measure(59) != res || s.Measure(0) {
+			t.Fatal("unexpected measurement result")
+		}
+		if len(s.Phases) != 1 {
+			t.Fatal("expected a single basis state")
+		}
+	}
+}
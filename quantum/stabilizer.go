@@ -0,0 +1,304 @@
+package quantum
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// A StabilizerSimulation is a classical simulation of a
+// quantum computer that can only run Clifford circuits.
+// It uses the tableau algorithm from
+// https://arxiv.org/abs/quant-ph/0406196, so it scales
+// polynomially in the number of qubits.
+//
+// Unitary only accepts single-qubit Clifford matrices
+// (e.g. H, X, Y, Z, S, and S*), up to a global phase.
+// Other matrices, like the T gate, cause a panic.
+type StabilizerSimulation struct {
+	numBits int
+
+	// Rows 0 through n-1 are destabilizers, rows n through
+	// 2n-1 are stabilizers, and row 2n is scratch space.
+	x [][]uint64
+	z [][]uint64
+	r []bool
+}
+
+// NewStabilizerSimulation creates a StabilizerSimulation
+// with all qubits set to 0.
+func NewStabilizerSimulation(numBits int) *StabilizerSimulation {
+	numWords := (numBits + 63) / 64
+	s := &StabilizerSimulation{
+		numBits: numBits,
+		x:       make([][]uint64, 2*numBits+1),
+		z:       make([][]uint64, 2*numBits+1),
+		r:       make([]bool, 2*numBits+1),
+	}
+	for i := range s.x {
+		s.x[i] = make([]uint64, numWords)
+		s.z[i] = make([]uint64, numWords)
+	}
+	for i := 0; i < numBits; i++ {
+		s.x[i][i/64] |= 1 << uint(i%64)
+		s.z[i+numBits][i/64] |= 1 << uint(i%64)
+	}
+	return s
+}
+
+func (s *StabilizerSimulation) NumBits() int {
+	return s.numBits
+}
+
+func (s *StabilizerSimulation) InUse(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= s.numBits {
+		panic("bit index out of range")
+	}
+	return false
+}
+
+func (s *StabilizerSimulation) Measure(bitIdx int) bool {
+	if bitIdx < 0 || bitIdx >= s.numBits {
+		panic("bit index out of range")
+	}
+	n := s.numBits
+	word, mask := bitIdx/64, uint64(1)<<uint(bitIdx%64)
+
+	p := -1
+	for i := n; i < 2*n; i++ {
+		if s.x[i][word]&mask != 0 {
+			p = i
+			break
+		}
+	}
+
+	if p == -1 {
+		// The outcome is deterministic.
+		scratch := 2 * n
+		for j := range s.x[scratch] {
+			s.x[scratch][j] = 0
+			s.z[scratch][j] = 0
+		}
+		s.r[scratch] = false
+		for i := 0; i < n; i++ {
+			if s.x[i][word]&mask != 0 {
+				s.rowSum(scratch, i+n)
+			}
+		}
+		return s.r[scratch]
+	}
+
+	for i := 0; i < 2*n; i++ {
+		if i != p && s.x[i][word]&mask != 0 {
+			s.rowSum(i, p)
+		}
+	}
+	copy(s.x[p-n], s.x[p])
+	copy(s.z[p-n], s.z[p])
+	s.r[p-n] = s.r[p]
+	for j := range s.x[p] {
+		s.x[p][j] = 0
+		s.z[p][j] = 0
+	}
+	s.z[p][word] = mask
+	s.r[p] = rand.Intn(2) == 1
+	return s.r[p]
+}
+
+func (s *StabilizerSimulation) Unitary(target int, m *Matrix2) {
+	if target < 0 || target >= s.numBits {
+		panic("bit index out of range")
+	}
+	word := cliffordWord(m)
+	if word == nil {
+		panic("unitary is not a Clifford gate")
+	}
+	for _, g := range word {
+		if g == 'H' {
+			s.hadamard(target)
+		} else {
+			s.phase(target)
+		}
+	}
+}
+
+func (s *StabilizerSimulation) CNot(control, target int) {
+	if control < 0 || control >= s.numBits || target < 0 || target >= s.numBits {
+		panic("bit index out of range")
+	}
+	if control == target {
+		panic("overlapping control and target is not invertible")
+	}
+	cWord, cShift := control/64, uint(control%64)
+	tWord, tShift := target/64, uint(target%64)
+	for i := range s.x {
+		xc := (s.x[i][cWord] >> cShift) & 1
+		zc := (s.z[i][cWord] >> cShift) & 1
+		xt := (s.x[i][tWord] >> tShift) & 1
+		zt := (s.z[i][tWord] >> tShift) & 1
+		if xc&zt&(xt^zc^1) != 0 {
+			s.r[i] = !s.r[i]
+		}
+		s.x[i][tWord] ^= xc << tShift
+		s.z[i][cWord] ^= zt << cShift
+	}
+}
+
+// Copy creates a deep copy of the simulation.
+func (s *StabilizerSimulation) Copy() *StabilizerSimulation {
+	res := &StabilizerSimulation{
+		numBits: s.numBits,
+		x:       make([][]uint64, len(s.x)),
+		z:       make([][]uint64, len(s.z)),
+		r:       append([]bool{}, s.r...),
+	}
+	for i := range s.x {
+		res.x[i] = append([]uint64{}, s.x[i]...)
+		res.z[i] = append([]uint64{}, s.z[i]...)
+	}
+	return res
+}
+
+// Stabilizers returns the generators of the stabilizer
+// group as signed Pauli strings, like "+XZI".
+// The i-th character of each string corresponds to the
+// i-th qubit.
+func (s *StabilizerSimulation) Stabilizers() []string {
+	var res []string
+	for i := s.numBits; i < 2*s.numBits; i++ {
+		var b strings.Builder
+		if s.r[i] {
+			b.WriteByte('-')
+		} else {
+			b.WriteByte('+')
+		}
+		for j := 0; j < s.numBits; j++ {
+			x, z := s.bits(i, j)
+			b.WriteByte("IZXY"[x<<1|z])
+		}
+		res = append(res, b.String())
+	}
+	return res
+}
+
+func (s *StabilizerSimulation) hadamard(bit int) {
+	word, shift := bit/64, uint(bit%64)
+	for i := range s.x {
+		x := (s.x[i][word] >> shift) & 1
+		z := (s.z[i][word] >> shift) & 1
+		if x&z != 0 {
+			s.r[i] = !s.r[i]
+		}
+		s.x[i][word] ^= (x ^ z) << shift
+		s.z[i][word] ^= (x ^ z) << shift
+	}
+}
+
+func (s *StabilizerSimulation) phase(bit int) {
+	word, shift := bit/64, uint(bit%64)
+	for i := range s.x {
+		x := (s.x[i][word] >> shift) & 1
+		z := (s.z[i][word] >> shift) & 1
+		if x&z != 0 {
+			s.r[i] = !s.r[i]
+		}
+		s.z[i][word] ^= x << shift
+	}
+}
+
+// rowSum multiplies row h by row i, storing the result
+// in row h.
+func (s *StabilizerSimulation) rowSum(h, i int) {
+	sum := 0
+	if s.r[h] {
+		sum += 2
+	}
+	if s.r[i] {
+		sum += 2
+	}
+	for j := 0; j < s.numBits; j++ {
+		x1, z1 := s.bits(i, j)
+		x2, z2 := s.bits(h, j)
+		sum += pauliProductPhase(x1, z1, x2, z2)
+	}
+	s.r[h] = ((sum%4)+4)%4 == 2
+	for j := range s.x[h] {
+		s.x[h][j] ^= s.x[i][j]
+		s.z[h][j] ^= s.z[i][j]
+	}
+}
+
+func (s *StabilizerSimulation) bits(row, bit int) (x, z int) {
+	word, shift := bit/64, uint(bit%64)
+	return int((s.x[row][word] >> shift) & 1), int((s.z[row][word] >> shift) & 1)
+}
+
+// pauliProductPhase computes the exponent of i which
+// results from multiplying two Pauli matrices.
+func pauliProductPhase(x1, z1, x2, z2 int) int {
+	if x1 == 0 && z1 == 0 {
+		return 0
+	} else if x1 == 1 && z1 == 1 {
+		return z2 - x2
+	} else if x1 == 1 {
+		return z2 * (2*x2 - 1)
+	} else {
+		return x2 * (1 - 2*z2)
+	}
+}
+
+// IsClifford checks if a matrix is a single-qubit
+// Clifford gate, up to a global phase.
+func IsClifford(m *Matrix2) bool {
+	return cliffordWord(m) != nil
+}
+
+type cliffordElement struct {
+	Matrix Matrix2
+	Word   []byte
+}
+
+var cliffordGroup = generateCliffordGroup()
+
+// cliffordWord finds a sequence of H and S gates which
+// implement the matrix, up to a global phase.
+//
+// The resulting word is applied in order, so "HS" means
+// H followed by S.
+//
+// If the matrix is not a Clifford gate, nil is returned.
+func cliffordWord(m *Matrix2) []byte {
+	for _, elem := range cliffordGroup {
+		if elem.Matrix.PhaseDistance(m) < 1e-8 {
+			return elem.Word
+		}
+	}
+	return nil
+}
+
+func generateCliffordGroup() []cliffordElement {
+	s := complex(1/math.Sqrt2, 0)
+	generators := map[byte]Matrix2{
+		'H': {s, s, s, -s},
+		'S': {1, 0, 0, 1i},
+	}
+	group := []cliffordElement{{Matrix: NewMatrix2(), Word: []byte{}}}
+	for i := 0; i < len(group); i++ {
+		for _, name := range []byte("HS") {
+			m := generators[name]
+			m.Mul(&group[i].Matrix)
+			found := false
+			for _, elem := range group {
+				if elem.Matrix.PhaseDistance(&m) < 1e-8 {
+					found = true
+					break
+				}
+			}
+			if !found {
+				word := append(append([]byte{}, group[i].Word...), name)
+				group = append(group, cliffordElement{Matrix: m, Word: word})
+			}
+		}
+	}
+	return group
+}
@@ -0,0 +1,97 @@
+package quantum
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestStabilizerSimulationStabilizers(t *testing.T) {
+	gates := []func(c Computer, bit int){H, X, Y, Z,
+		func(c Computer, bit int) {
+			c.Unitary(bit, &Matrix2{1, 0, 0, 1i})
+		},
+		func(c Computer, bit int) {
+			c.Unitary(bit, &Matrix2{1, 0, 0, -1i})
+		},
+		func(c Computer, bit int) {
+			c.Unitary(bit, &Matrix2{(1 + 1i) / 2, (1 - 1i) / 2, (1 - 1i) / 2, (1 + 1i) / 2})
+		},
+	}
+	for i := 0; i < 50; i++ {
+		stab := NewStabilizerSimulation(5)
+		sim := NewSimulation(5)
+		for j := 0; j < 40; j++ {
+			if rand.Intn(3) == 0 {
+				control := rand.Intn(5)
+				target := (control + rand.Intn(4) + 1) % 5
+				stab.CNot(control, target)
+				sim.CNot(control, target)
+			} else {
+				gate := gates[rand.Intn(len(gates))]
+				bit := rand.Intn(5)
+				gate(stab, bit)
+				gate(sim, bit)
+			}
+		}
+		for _, pauli := range stab.Stabilizers() {
+			sim1 := sim.Copy()
+			applyPauliString(sim1, pauli)
+			if !sim1.ApproxEqual(sim, 1e-8) {
+				t.Fatalf("state is not stabilized by %s", pauli)
+			}
+		}
+	}
+}
+
+func TestStabilizerSimulationMeasure(t *testing.T) {
+	const numBits = 300
+	for i := 0; i < 4; i++ {
+		s := NewStabilizerSimulation(numBits)
+		H(s, 0)
+		for j := 1; j < numBits; j++ {
+			s.CNot(j-1, j)
+		}
+		first := s.Measure(numBits / 2)
+		for j := 0; j < numBits; j++ {
+			if s.Measure(j) != first {
+				t.Fatal("GHZ state measurements disagree")
+			}
+		}
+	}
+
+	s := NewStabilizerSimulation(3)
+	X(s, 1)
+	H(s, 2)
+	Z(s, 2)
+	H(s, 2)
+	if s.Measure(0) || !s.Measure(1) || !s.Measure(2) {
+		t.Error("unexpected deterministic measurements")
+	}
+}
+
+func TestStabilizerSimulationNonClifford(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for T gate")
+		}
+	}()
+	T(NewStabilizerSimulation(1), 0)
+}
+
+func applyPauliString(s *Simulation, pauli string) {
+	if pauli[0] == '-' {
+		for i := range s.Phases {
+			s.Phases[i] *= -1
+		}
+	}
+	for i, p := range pauli[1:] {
+		switch p {
+		case 'X':
+			X(s, i)
+		case 'Y':
+			Y(s, i)
+		case 'Z':
+			Z(s, i)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package quantum
+
+import (
+	"math"
+	"math/cmplx"
+	"strings"
+)
+
+// A Matrix is a dense 2^n by 2^n matrix acting on n
+// qubits, stored in row-major order.
+//
+// Basis states are indexed like Simulation.Phases, so
+// bit i of a row or column index is qubit i.
+type Matrix struct {
+	NumBits int
+	Data    []complex128
+}
+
+// NewMatrix creates the identity on numBits qubits.
+func NewMatrix(numBits int) *Matrix {
+	dim := 1 << uint(numBits)
+	res := &Matrix{NumBits: numBits, Data: make([]complex128, dim*dim)}
+	for i := 0; i < dim; i++ {
+		res.Data[i*dim+i] = 1
+	}
+	return res
+}
+
+// ExtractUnitary computes the matrix implemented by an
+// Applier on numBits qubits, by running it on every
+// basis state.
+//
+// The Applier should not perform measurements.
+func ExtractUnitary(numBits int, a Applier) *Matrix {
+	dim := 1 << uint(numBits)
+	res := &Matrix{NumBits: numBits, Data: make([]complex128, dim*dim)}
+	for col := 0; col < dim; col++ {
+		s := NewSimulationBits(numBits, uint(col))
+		a.Apply(s)
+		for row, ph := range s.Phases {
+			res.Data[row*dim+col] = ph
+		}
+	}
+	return res
+}
+
+// Dim gets the number of rows (and columns).
+func (m *Matrix) Dim() int {
+	return 1 << uint(m.NumBits)
+}
+
+// At gets the entry at the given row and column.
+func (m *Matrix) At(row, col int) complex128 {
+	return m.Data[row*m.Dim()+col]
+}
+
+// Mul computes the product m*m1.
+func (m *Matrix) Mul(m1 *Matrix) *Matrix {
+	if m.NumBits != m1.NumBits {
+		panic("mismatching number of bits")
+	}
+	dim := m.Dim()
+	res := &Matrix{NumBits: m.NumBits, Data: make([]complex128, dim*dim)}
+	for i := 0; i < dim; i++ {
+		for k := 0; k < dim; k++ {
+			x := m.Data[i*dim+k]
+			if x == 0 {
+				continue
+			}
+			row := res.Data[i*dim : (i+1)*dim]
+			for j, y := range m1.Data[k*dim : (k+1)*dim] {
+				row[j] += x * y
+			}
+		}
+	}
+	return res
+}
+
+// ConjTranspose computes the conjugate transpose.
+func (m *Matrix) ConjTranspose() *Matrix {
+	dim := m.Dim()
+	res := &Matrix{NumBits: m.NumBits, Data: make([]complex128, dim*dim)}
+	for i := 0; i < dim; i++ {
+		for j := 0; j < dim; j++ {
+			res.Data[j*dim+i] = cmplx.Conj(m.Data[i*dim+j])
+		}
+	}
+	return res
+}
+
+// ApproxEqual checks if two matrices are equal up to a
+// tolerance on each entry.
+func (m *Matrix) ApproxEqual(m1 *Matrix, tol float64) bool {
+	if m.NumBits != m1.NumBits {
+		return false
+	}
+	for i, x := range m.Data {
+		if cmplx.Abs(x-m1.Data[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// ApproxEqualPhase checks if two matrices are equal up to
+// a global phase and a tolerance on each entry.
+func (m *Matrix) ApproxEqualPhase(m1 *Matrix, tol float64) bool {
+	if m.NumBits != m1.NumBits {
+		return false
+	}
+
+	// Use the largest entry to find the relative phase,
+	// since it is the least sensitive to rounding.
+	var maxIdx int
+	for i, x := range m.Data {
+		if cmplx.Abs(x) > cmplx.Abs(m.Data[maxIdx]) {
+			maxIdx = i
+		}
+	}
+	if cmplx.Abs(m1.Data[maxIdx]) < epsilon {
+		return m.ApproxEqual(m1, tol)
+	}
+	phase := m.Data[maxIdx] / m1.Data[maxIdx]
+	phase /= complex(cmplx.Abs(phase), 0)
+	for i, x := range m.Data {
+		if cmplx.Abs(x-phase*m1.Data[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// IsUnitary checks if m^H*m is the identity, up to a
+// tolerance on each entry.
+func (m *Matrix) IsUnitary(tol float64) bool {
+	return m.ConjTranspose().Mul(m).ApproxEqual(NewMatrix(m.NumBits), tol)
+}
+
+// String formats the matrix as a grid, with one line per
+// row and columns aligned.
+func (m *Matrix) String() string {
+	dim := m.Dim()
+	cells := make([]string, len(m.Data))
+	widths := make([]int, dim)
+	for i, x := range m.Data {
+		if math.Abs(real(x)) < epsilon {
+			x = complex(0, imag(x))
+		}
+		if math.Abs(imag(x)) < epsilon {
+			x = complex(real(x), 0)
+		}
+		cells[i] = formatPhase(x)
+		if len(cells[i]) > widths[i%dim] {
+			widths[i%dim] = len(cells[i])
+		}
+	}
+	var lines []string
+	for row := 0; row < dim; row++ {
+		var line []string
+		for col, cell := range cells[row*dim : (row+1)*dim] {
+			line = append(line, strings.Repeat(" ", widths[col]-len(cell))+cell)
+		}
+		lines = append(lines, "["+strings.Join(line, " ")+"]")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,98 @@
+package quantum
+
+import (
+	"fmt"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+func ExampleExtractUnitary() {
+	fmt.Println(ExtractUnitary(2, FnApplier(func(c Computer) {
+		c.CNot(0, 1)
+	})))
+
+	// Output:
+	// [1 0 0 0]
+	// [0 0 0 1]
+	// [0 0 1 0]
+	// [0 1 0 0]
+}
+
+func TestExtractUnitary(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		circuit := randomizedCircuit(rand.Perm(4), rand.Int(), 20)
+		m := ExtractUnitary(4, circuit)
+		if !m.IsUnitary(1e-8) {
+			t.Fatal("result is not unitary")
+		}
+		s1 := RandomSimulation(4)
+		s2 := s1.Copy()
+		circuit.Apply(s1)
+		for row := range s2.Phases {
+			var sum complex128
+			for col, ph := range s2.Phases {
+				sum += m.At(row, col) * ph
+			}
+			if cmplx.Abs(sum-s1.Phases[row]) > 1e-8 {
+				t.Fatal("incorrect matrix")
+			}
+		}
+		inv := ExtractUnitary(4, circuit.Inverse())
+		if !inv.ApproxEqual(m.ConjTranspose(), 1e-8) {
+			t.Fatal("inverse circuit does not match conjugate transpose")
+		}
+	}
+}
+
+func TestMatrixApproxEqualPhase(t *testing.T) {
+	// Swap(a, b) is equivalent to three CNOTs, and SqrtNot
+	// is equivalent to H*S*H up to a global phase.
+	swap := ExtractUnitary(3, FnApplier(func(c Computer) {
+		Swap(c, 0, 2)
+	}))
+	cnots := ExtractUnitary(3, FnApplier(func(c Computer) {
+		c.CNot(0, 2)
+		c.CNot(2, 0)
+		c.CNot(0, 2)
+	}))
+	if !swap.ApproxEqual(cnots, 1e-8) {
+		t.Error("swap mismatch")
+	}
+
+	sqrtNot := ExtractUnitary(1, FnApplier(func(c Computer) {
+		SqrtNot(c, 0)
+	}))
+	hsh := ExtractUnitary(1, FnApplier(func(c Computer) {
+		H(c, 0)
+		T(c, 0)
+		T(c, 0)
+		H(c, 0)
+	}))
+	hsdgh := ExtractUnitary(1, FnApplier(func(c Computer) {
+		H(c, 0)
+		InvT(c, 0)
+		InvT(c, 0)
+		H(c, 0)
+	}))
+	if sqrtNot.ApproxEqual(hsh, 1e-8) && sqrtNot.ApproxEqual(hsdgh, 1e-8) {
+		t.Error("unexpected exact equality")
+	}
+	if !sqrtNot.ApproxEqualPhase(hsh, 1e-8) && !sqrtNot.ApproxEqualPhase(hsdgh, 1e-8) {
+		t.Error("expected equality up to phase")
+	}
+	if hsh.ApproxEqualPhase(hsdgh, 1e-8) {
+		t.Error("unexpected equality up to phase")
+	}
+}
+
+func TestMatrixIsUnitary(t *testing.T) {
+	m := NewMatrix(2)
+	if !m.IsUnitary(1e-8) {
+		t.Error("identity should be unitary")
+	}
+	m.Data[1] = 0.5
+	if m.IsUnitary(1e-8) {
+		t.Error("matrix should not be unitary")
+	}
+}
@@ -0,0 +1,105 @@
+package quantum
+
+import "math"
+
+// An Optimizer updates parameters using their gradients.
+type Optimizer interface {
+	// Step updates values in place to decrease an
+	// objective with the given gradient.
+	Step(values, grad map[string]float64)
+}
+
+// SGD is an Optimizer which performs gradient descent
+// with optional momentum.
+type SGD struct {
+	LearningRate float64
+	Momentum     float64
+
+	velocity map[string]float64
+}
+
+func (s *SGD) Step(values, grad map[string]float64) {
+	if s.velocity == nil {
+		s.velocity = map[string]float64{}
+	}
+	for name, g := range grad {
+		v := s.Momentum*s.velocity[name] + g
+		s.velocity[name] = v
+		values[name] -= s.LearningRate * v
+	}
+}
+
+// Adam is an Optimizer implementing the Adam update rule.
+type Adam struct {
+	LearningRate float64
+	Beta1        float64
+	Beta2        float64
+	Epsilon      float64
+
+	numSteps int
+	moment1  map[string]float64
+	moment2  map[string]float64
+}
+
+// NewAdam creates an Adam optimizer with the default
+// hyperparameters from the original paper.
+func NewAdam(learningRate float64) *Adam {
+	return &Adam{
+		LearningRate: learningRate,
+		Beta1:        0.9,
+		Beta2:        0.999,
+		Epsilon:      1e-8,
+	}
+}
+
+func (a *Adam) Step(values, grad map[string]float64) {
+	if a.moment1 == nil {
+		a.moment1 = map[string]float64{}
+		a.moment2 = map[string]float64{}
+	}
+	a.numSteps++
+	correction1 := 1 - math.Pow(a.Beta1, float64(a.numSteps))
+	correction2 := 1 - math.Pow(a.Beta2, float64(a.numSteps))
+	for name, g := range grad {
+		m := a.Beta1*a.moment1[name] + (1-a.Beta1)*g
+		v := a.Beta2*a.moment2[name] + (1-a.Beta2)*g*g
+		a.moment1[name] = m
+		a.moment2[name] = v
+		mHat := m / correction1
+		vHat := v / correction2
+		values[name] -= a.LearningRate * mHat / (math.Sqrt(vHat) + a.Epsilon)
+	}
+}
+
+// MinimizeExpectation runs an optimizer for the given
+// number of steps to minimize the expectation of an
+// observable after applying a parameterized circuit to
+// the all-zero state.
+//
+// The values are updated in place, and must initially
+// contain every free parameter. Gradients are computed
+// with gradFn, such as ParamShiftGradient or
+// AdjointGradient. If gradFn is nil, AdjointGradient is
+// used.
+//
+// The returned slice contains the expectation before each
+// step, followed by the final expectation.
+func MinimizeExpectation(numBits int, c Circuit, o Observable, opt Optimizer,
+	gradFn GradientFunc, values map[string]float64, steps int) []float64 {
+	var history []float64
+	for i := 0; i < steps; i++ {
+		var expectation float64
+		var grad map[string]float64
+		if gradFn == nil {
+			// The adjoint method computes the expectation
+			// for free.
+			expectation, grad = adjointGradient(numBits, c, o, values)
+		} else {
+			expectation = CircuitExpectation(numBits, c, o, values)
+			grad = gradFn(numBits, c, o, values)
+		}
+		history = append(history, expectation)
+		opt.Step(values, grad)
+	}
+	return append(history, CircuitExpectation(numBits, c, o, values))
+}
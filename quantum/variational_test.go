@@ -0,0 +1,40 @@
+package quantum
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMinimizeExpectation(t *testing.T) {
+	circuit := Circuit{
+		&ParamGate{Kind: "RY", Bit: 0, Angles: []Param{NewParam("a")}},
+		&ParamGate{Kind: "RX", Bit: 1, Angles: []Param{NewParam("b")}},
+		&CNotGate{Control: 0, Target: 1},
+	}
+	// The expectation is -cos(a) + cos(a)*cos(b), which is
+	// minimized at a=0, b=pi.
+	obs := PauliSum{{Coeff: -1, Paulis: "ZI"}, {Coeff: 1, Paulis: "IZ"}}
+	optimizers := map[string]func() Optimizer{
+		"SGD":  func() Optimizer { return &SGD{LearningRate: 0.1, Momentum: 0.9} },
+		"Adam": func() Optimizer { return NewAdam(0.05) },
+	}
+	gradFns := map[string]GradientFunc{
+		"Default":    nil,
+		"ParamShift": ParamShiftGradient,
+		"Adjoint":    AdjointGradient,
+	}
+	for optName, makeOpt := range optimizers {
+		for gradName, gradFn := range gradFns {
+			name := optName + "/" + gradName
+			values := map[string]float64{"a": 0.1, "b": 0.2}
+			history := MinimizeExpectation(2, circuit, obs, makeOpt(), gradFn, values, 300)
+			if len(history) != 301 {
+				t.Fatalf("%s: unexpected history length %d", name, len(history))
+			}
+			final := history[len(history)-1]
+			if math.Abs(final+2) > 1e-3 {
+				t.Errorf("%s: expected -2 but got %f (values %v)", name, final, values)
+			}
+		}
+	}
+}